@@ -151,6 +151,31 @@ func (e *ESClient) UpdateIndexDoc(index, docid, doc string) error {
 	return respError(res)
 }
 
+// Return the source of doc indicated by given index and doc id, it return
+// invar.ErrNotFound when doc unexist.
+func (e *ESClient) GetIndexDoc(index, docid string) (json.RawMessage, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.Get(index, docid)
+	if err != nil {
+		esclog.E("Get index doc, err:", err)
+		return nil, err
+	} else if res.StatusCode == invar.E404Exception {
+		res.Body.Close()
+		return nil, invar.ErrNotFound
+	}
+
+	doc := struct {
+		Source json.RawMessage `json:"_source"`
+	}{}
+	if err := decodeResp(res, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Source, nil
+}
+
 // Delete the exist doc indicated by given index and doc id.
 func (e *ESClient) DeleteIndexDoc(index, docid string) error {
 	if e.Conn == nil {
@@ -235,3 +260,20 @@ func respError(res *esapi.Response) error {
 	_, err := readResponse(res)
 	return err
 }
+
+// Decode response body into out, or return response error.
+func decodeResp(res *esapi.Response, out any) error {
+	defer res.Body.Close()
+	if res.IsError() {
+		_, err := readResponse(res)
+		return err
+	} else if out == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return errors.New("Decode resp, err:" + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Bulk actions of bulk item.
+const (
+	BulkIndex  = "index"  // Create or replace document
+	BulkCreate = "create" // Create document, failed when exist
+	BulkUpdate = "update" // Partial update document fields
+	BulkDelete = "delete" // Delete document
+)
+
+// Bulk indexer options, the zero values use the defaults.
+type BulkOptions struct {
+	Workers       int                                            // Concurrent workers, default runtime.NumCPU()
+	FlushCount    int                                            // Flush when buffered actions reached, default 1000
+	FlushBytes    int                                            // Flush when buffered bytes reached, default 5MB
+	FlushInterval time.Duration                                  // Flush buffered actions periodically, default 1s
+	MaxRetries    int                                            // Max retries of 429 rejected actions, default 3
+	Backoff       func(retry int) time.Duration                  // Retry backoff, default exponential from 100ms to 10s
+	Refresh       string                                         // Refresh policy as 'true', 'false', 'wait_for', default empty
+	OnError       func(item *BulkItem, err error)                // Callback of failed item, optional
+	OnFlush       func(count int, bytes int, took time.Duration) // Callback after flushed, optional
+}
+
+// Bulk action item to add into bulk indexer.
+type BulkItem struct {
+	Action string // Bulk action, one of BulkIndex, BulkCreate, BulkUpdate, BulkDelete
+	Index  string // Target index name
+	DocID  string // Document id, optional for index and create actions
+	Doc    any    // Document to index, or partial fields to update
+
+	meta, body []byte // Encoded action lines
+}
+
+// Bulk indexer statistics.
+type BulkStats struct {
+	Added   uint64 // Added items count
+	Indexed uint64 // Succeed items count
+	Failed  uint64 // Failed items count
+	Retried uint64 // Retried items count of 429 rejected
+	Flushed uint64 // Flushed bulk requests count
+	Bytes   uint64 // Flushed bytes of bulk requests
+}
+
+// Bulk item error returned by elasticsearch.
+type BulkError struct {
+	Status int    // Item response status
+	Type   string // Error type
+	Reason string // Error reason
+}
+
+// Bulk indexer to batch index, update, delete actions by concurrent workers,
+// it can be used for one-off backfill, or long-lived sink.
+//
+// # USAGE:
+//
+//	bi, _ := elastic.GetEs().NewBulkIndexer(&elastic.BulkOptions{
+//		Workers: 4, OnError: func(item *elastic.BulkItem, err error) {
+//			logger.E("Bulk", item.Action, item.Index, item.DocID, "err:", err)
+//		},
+//	})
+//	for _, product := range products {
+//		bi.Index("products", product.ID, product)
+//	}
+//	bi.Close(ctx) // flush remain items and wait all workers exist.
+//	logger.I("Bulk stats:", bi.Stats())
+type BulkIndexer struct {
+	do      bulkFunc
+	opts    *BulkOptions
+	items   chan *BulkItem
+	flushes []chan chan struct{} // Flush signal chanels of workers
+	lock    sync.RWMutex         // Lock to close items chanel safely
+	wg      sync.WaitGroup
+	closed  bool
+	stats   bulkStats
+}
+
+// Bulk request executor of client, the refresh policy ignored when empty.
+type bulkFunc func(body io.Reader, refresh string) (*esapi.Response, error)
+
+// Atomic counters of bulk statistics.
+type bulkStats struct {
+	added, indexed, failed, retried, flushed, bytes atomic.Uint64
+}
+
+// Bulk worker with buffered actions.
+type bulkWorker struct {
+	bi    *BulkIndexer
+	flush chan chan struct{}
+	items []*BulkItem
+	size  int
+}
+
+// Bulk response datas.
+type bulkResp struct {
+	Errors bool                       `json:"errors"`
+	Items  []map[string]*bulkRespItem `json:"items"`
+}
+
+// Bulk response item datas.
+type bulkRespItem struct {
+	Index  string  `json:"_index"`
+	ID     string  `json:"_id"`
+	Status int     `json:"status"`
+	Error  *Reason `json:"error,omitempty"`
+}
+
+// Return the error string of bulk item.
+func (e *BulkError) Error() string {
+	return e.Type + ": " + e.Reason
+}
+
+// Create and start bulk indexer with options.
+func (e *ESClient) NewBulkIndexer(opts *BulkOptions) (*BulkIndexer, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+	return newBulkIndexer(e.bulk, opts), nil
+}
+
+// Add a bulk item, it return error when item invalid or indexer closed,
+// and block when the items queue full.
+func (bi *BulkIndexer) Add(ctx context.Context, item *BulkItem) error {
+	if err := item.encode(); err != nil {
+		return err
+	}
+
+	bi.lock.RLock()
+	defer bi.lock.RUnlock()
+	if bi.closed {
+		return invar.ErrInvalidState
+	}
+
+	select {
+	case bi.items <- item:
+		bi.stats.added.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Add index action to create or replace document.
+func (bi *BulkIndexer) Index(index, docid string, doc any) error {
+	return bi.Add(context.Background(), &BulkItem{Action: BulkIndex, Index: index, DocID: docid, Doc: doc})
+}
+
+// Add update action to update document fields partially.
+func (bi *BulkIndexer) Update(index, docid string, fields any) error {
+	return bi.Add(context.Background(), &BulkItem{Action: BulkUpdate, Index: index, DocID: docid, Doc: fields})
+}
+
+// Add delete action to delete document.
+func (bi *BulkIndexer) Delete(index, docid string) error {
+	return bi.Add(context.Background(), &BulkItem{Action: BulkDelete, Index: index, DocID: docid})
+}
+
+// Flush the queued and buffered items of all workers, and wait them sent,
+// so all items added before flush reached elasticsearch when it returned.
+func (bi *BulkIndexer) Flush(ctx context.Context) error {
+	bi.lock.RLock()
+	defer bi.lock.RUnlock()
+	if bi.closed {
+		return invar.ErrInvalidState
+	}
+
+	for _, flush := range bi.flushes {
+		done := make(chan struct{})
+		select {
+		case flush <- done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close the bulk indexer, it flush all remain items and wait workers exist.
+func (bi *BulkIndexer) Close(ctx context.Context) error {
+	bi.lock.Lock()
+	if bi.closed {
+		bi.lock.Unlock()
+		return nil
+	}
+	bi.closed = true
+	close(bi.items)
+	bi.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() { bi.wg.Wait(); close(done) }()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Return the current statistics.
+func (bi *BulkIndexer) Stats() BulkStats {
+	return BulkStats{
+		Added: bi.stats.added.Load(), Indexed: bi.stats.indexed.Load(),
+		Failed: bi.stats.failed.Load(), Retried: bi.stats.retried.Load(),
+		Flushed: bi.stats.flushed.Load(), Bytes: bi.stats.bytes.Load(),
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Create and start bulk indexer with the bulk request executor.
+func newBulkIndexer(do bulkFunc, opts *BulkOptions) *BulkIndexer {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.FlushCount <= 0 {
+		opts.FlushCount = 1000
+	}
+	if opts.FlushBytes <= 0 {
+		opts.FlushBytes = 5 * 1024 * 1024
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = func(retry int) time.Duration {
+			return min(100*time.Millisecond<<retry, 10*time.Second)
+		}
+	}
+
+	bi := &BulkIndexer{
+		do: do, opts: opts,
+		items: make(chan *BulkItem, opts.Workers*opts.FlushCount),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		worker := &bulkWorker{bi: bi, flush: make(chan chan struct{})}
+		bi.flushes = append(bi.flushes, worker.flush)
+		bi.wg.Add(1)
+		go worker.run()
+	}
+	return bi
+}
+
+// Encode bulk item to meta and body lines.
+func (item *BulkItem) encode() error {
+	meta := map[string]string{"_index": item.Index}
+	if item.DocID != "" {
+		meta["_id"] = item.DocID
+	}
+
+	switch item.Action {
+	case BulkIndex, BulkCreate:
+		if item.Doc == nil {
+			return invar.ErrInvalidParams
+		}
+	case BulkUpdate:
+		if item.DocID == "" || item.Doc == nil {
+			return invar.ErrInvalidParams
+		}
+	case BulkDelete:
+		if item.DocID == "" {
+			return invar.ErrInvalidParams
+		}
+	default:
+		return invar.ErrNotSupport
+	}
+
+	var err error
+	if item.meta, err = json.Marshal(map[string]any{item.Action: meta}); err != nil {
+		return err
+	}
+
+	switch item.Action {
+	case BulkIndex, BulkCreate:
+		item.body, err = json.Marshal(item.Doc)
+	case BulkUpdate:
+		item.body, err = json.Marshal(map[string]any{"doc": item.Doc})
+	}
+	return err
+}
+
+// Return the encoded bytes of bulk item.
+func (item *BulkItem) size() int {
+	if item.body == nil {
+		return len(item.meta) + 1
+	}
+	return len(item.meta) + len(item.body) + 2
+}
+
+// Run worker to receive items and flush periodically.
+func (w *bulkWorker) run() {
+	defer w.bi.wg.Done()
+	ticker := time.NewTicker(w.bi.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-w.bi.items:
+			if !ok {
+				w.send()
+				return
+			}
+
+			w.items, w.size = append(w.items, item), w.size+item.size()
+			if len(w.items) >= w.bi.opts.FlushCount || w.size >= w.bi.opts.FlushBytes {
+				w.send()
+			}
+		case done := <-w.flush:
+			w.drain()
+			w.send()
+			close(done)
+		case <-ticker.C:
+			w.send()
+		}
+	}
+}
+
+// Receive the queued items without blocking, so the items added before
+// flush all sent, and send the buffered items when reached flush limits.
+func (w *bulkWorker) drain() {
+	for {
+		select {
+		case item, ok := <-w.bi.items:
+			if !ok {
+				return
+			}
+
+			w.items, w.size = append(w.items, item), w.size+item.size()
+			if len(w.items) >= w.bi.opts.FlushCount || w.size >= w.bi.opts.FlushBytes {
+				w.send()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Send buffered items, and retry the 429 rejected items with backoff.
+func (w *bulkWorker) send() {
+	items := w.items
+	w.items, w.size = nil, 0
+
+	for retry := 0; len(items) > 0; retry++ {
+		rejected, err := w.bi.bulk(items)
+		if err != nil {
+			w.bi.fail(items, err)
+			return
+		} else if len(rejected) == 0 {
+			return
+		} else if retry >= w.bi.opts.MaxRetries {
+			w.bi.fail(rejected, &BulkError{Status: http.StatusTooManyRequests, Type: "rejected", Reason: "too many requests"})
+			return
+		}
+
+		w.bi.stats.retried.Add(uint64(len(rejected)))
+		time.Sleep(w.bi.opts.Backoff(retry))
+		items = rejected
+	}
+}
+
+// Execute bulk request and return the 429 rejected items to retry.
+func (bi *BulkIndexer) bulk(items []*BulkItem) ([]*BulkItem, error) {
+	buf := &bytes.Buffer{}
+	for _, item := range items {
+		buf.Write(item.meta)
+		buf.WriteByte('\n')
+		if item.body != nil {
+			buf.Write(item.body)
+			buf.WriteByte('\n')
+		}
+	}
+
+	start, size := time.Now(), buf.Len()
+	res, err := bi.do(buf, bi.opts.Refresh)
+	if err != nil {
+		esclog.E("Bulk", len(items), "items, err:", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	bi.stats.flushed.Add(1)
+	bi.stats.bytes.Add(uint64(size))
+	if res.StatusCode == http.StatusTooManyRequests {
+		io.Copy(io.Discard, res.Body)
+		return items, nil
+	} else if res.IsError() {
+		_, err := readResponse(res)
+		return nil, err
+	}
+
+	resp := &bulkResp{}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, errors.New("Decode bulk resp, err:" + err.Error())
+	}
+
+	rejected := []*BulkItem{}
+	for i, ritem := range resp.Items {
+		if i >= len(items) {
+			break
+		}
+
+		for _, result := range ritem {
+			switch item := items[i]; {
+			case result.Status == http.StatusTooManyRequests:
+				rejected = append(rejected, item)
+			case result.Status >= 300 || result.Error != nil:
+				berr := &BulkError{Status: result.Status}
+				if result.Error != nil {
+					berr.Type, berr.Reason = result.Error.Type, result.Error.Reason
+				}
+				bi.fail([]*BulkItem{item}, berr)
+			default:
+				bi.stats.indexed.Add(1)
+			}
+		}
+	}
+
+	if bi.opts.OnFlush != nil {
+		bi.opts.OnFlush(len(items), size, time.Since(start))
+	}
+	return rejected, nil
+}
+
+// Execute bulk request by elasticsearch connection.
+func (e *ESClient) bulk(body io.Reader, refresh string) (*esapi.Response, error) {
+	opts := []func(*esapi.BulkRequest){}
+	if refresh != "" {
+		opts = append(opts, e.Conn.Bulk.WithRefresh(refresh))
+	}
+	return e.Conn.Bulk(body, opts...)
+}
+
+// Count failed items and notify error callback.
+func (bi *BulkIndexer) fail(items []*BulkItem, err error) {
+	bi.stats.failed.Add(uint64(len(items)))
+	if bi.opts.OnError != nil {
+		for _, item := range items {
+			bi.opts.OnError(item, err)
+		}
+	}
+}
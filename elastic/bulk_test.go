@@ -0,0 +1,193 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	es "github.com/elastic/go-elasticsearch/v8"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/elastic, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Create elastic client of fake server which count the received action
+// items, and response the item status by the given function of document
+// id and received times, or accept all actions when the function is nil.
+func newBulkServer(t *testing.T, received *atomic.Int64, status func(docid string, times int) int) *ESClient {
+	lock, counts := sync.Mutex{}, map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		items := []map[string]*bulkRespItem{}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			meta := map[string]map[string]string{}
+			if err := json.Unmarshal(scanner.Bytes(), &meta); err != nil {
+				continue
+			}
+			for action, m := range meta {
+				item := &bulkRespItem{Index: m["_index"], ID: m["_id"], Status: http.StatusOK}
+				if status != nil {
+					lock.Lock()
+					counts[item.ID]++
+					item.Status = status(item.ID, counts[item.ID])
+					lock.Unlock()
+				}
+				if item.Status >= http.StatusBadRequest && item.Status != http.StatusTooManyRequests {
+					item.Error = &Reason{Type: "mapper_parsing_exception", Reason: "failed to parse"}
+				}
+				items = append(items, map[string]*bulkRespItem{action: item})
+				if action != BulkDelete {
+					scanner.Scan() // skip document line
+				}
+			}
+		}
+
+		received.Add(int64(len(items)))
+		json.NewEncoder(w).Encode(&bulkResp{Items: items})
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := es.NewClient(es.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatal("Create elastic client, err:", err)
+	}
+	return &ESClient{Conn: conn}
+}
+
+// Test BulkIndexer.Flush send all items added before flush.
+func TestBulkFlush(t *testing.T) {
+	cases := []struct {
+		Case    string
+		Workers int
+		Count   int
+		Items   int
+	}{
+		{"Flush single worker", 1, 100, 50},
+		{"Flush multiple workers", 4, 10, 1000},
+		{"Flush over queue size", 2, 3, 500},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			received := &atomic.Int64{}
+			bi, err := newBulkServer(t, received, nil).NewBulkIndexer(&BulkOptions{
+				Workers: c.Workers, FlushCount: c.Count, FlushInterval: time.Hour,
+			})
+			if err != nil {
+				t.Fatal("Create bulk indexer, err:", err)
+			}
+			defer bi.Close(context.Background())
+
+			for i := 0; i < c.Items; i++ {
+				if err := bi.Index("products", strconv.Itoa(i), map[string]int{"seq": i}); err != nil {
+					t.Fatal("Add item, err:", err)
+				}
+			}
+
+			if err := bi.Flush(context.Background()); err != nil {
+				t.Fatal("Flush items, err:", err)
+			} else if sent := received.Load(); sent != int64(c.Items) {
+				t.Fatal("Unsent items after flush, sent:", sent, "added:", c.Items)
+			} else if stats := bi.Stats(); stats.Indexed != uint64(c.Items) {
+				t.Fatal("Unmatched indexed stats:", stats)
+			}
+		})
+	}
+}
+
+// Test BulkIndexer retry the 429 rejected items, and report failed items.
+func TestBulkRetry(t *testing.T) {
+	cases := []struct {
+		Case    string
+		Retries int
+		Status  func(docid string, times int) int
+		Indexed uint64
+		Failed  uint64
+		Retried uint64
+		Code    int // Status of failed items
+	}{
+		{"Retry until accepted", 3, func(docid string, times int) int {
+			if strings.HasPrefix(docid, "r") && times <= 2 {
+				return http.StatusTooManyRequests
+			}
+			return http.StatusCreated
+		}, 10, 0, 10, 0},
+		{"Retries exhausted", 2, func(docid string, times int) int {
+			if strings.HasPrefix(docid, "r") {
+				return http.StatusTooManyRequests
+			}
+			return http.StatusCreated
+		}, 5, 5, 10, http.StatusTooManyRequests},
+		{"Item errors not retried", 3, func(docid string, times int) int {
+			if strings.HasPrefix(docid, "r") {
+				return http.StatusBadRequest
+			}
+			return http.StatusCreated
+		}, 5, 5, 0, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			lock, failed := sync.Mutex{}, map[string]int{}
+			received := &atomic.Int64{}
+			bi, err := newBulkServer(t, received, c.Status).NewBulkIndexer(&BulkOptions{
+				Workers: 1, FlushCount: 100, FlushInterval: time.Hour, MaxRetries: c.Retries,
+				Backoff: func(retry int) time.Duration { return time.Millisecond },
+				OnError: func(item *BulkItem, err error) {
+					lock.Lock()
+					defer lock.Unlock()
+					if berr, ok := err.(*BulkError); ok {
+						failed[item.DocID] = berr.Status
+					}
+				},
+			})
+			if err != nil {
+				t.Fatal("Create bulk indexer, err:", err)
+			}
+
+			for i := 0; i < 5; i++ { // the 'r' prefix documents rejected or failed.
+				bi.Index("products", "r"+strconv.Itoa(i), map[string]int{"seq": i})
+				bi.Index("products", "a"+strconv.Itoa(i), map[string]int{"seq": i})
+			}
+			if err := bi.Close(context.Background()); err != nil {
+				t.Fatal("Close bulk indexer, err:", err)
+			}
+
+			stats := bi.Stats()
+			if stats.Indexed != c.Indexed || stats.Failed != c.Failed || stats.Retried != c.Retried {
+				t.Fatal("Unmatched bulk stats:", stats)
+			} else if len(failed) != int(c.Failed) {
+				t.Fatal("Unmatched failed callbacks:", failed)
+			}
+			for docid, code := range failed {
+				if !strings.HasPrefix(docid, "r") || code != c.Code {
+					t.Fatal("Unexpected failed item:", docid, "status:", code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+	pd "github.com/wengoldx/xcore/mvc/provider"
+	"github.com/wengoldx/xcore/mvc/provider/provider"
+)
+
+// Change actions of table rows written into outbox.
+const (
+	ChangeInsert = "insert" // Rows inserted
+	ChangeUpdate = "update" // Rows updated
+	ChangeDelete = "delete" // Rows deleted
+)
+
+// Table sync config to map database rows to index documents.
+type SyncTable struct {
+	Index string                                          // Target index or alias name
+	Load  func(keys []string) (map[string]any, error)     // Load documents by rows keys, the returned map key used as document id, and the missing keys deleted from index
+	Scan  func(after string, limit int) ([]string, error) // Return the keys ordered after the given key for full resync, the first page start by empty key
+}
+
+// Options of database sync, the zero values use the defaults.
+type SyncOptions struct {
+	Interval   time.Duration                 // Polling interval of outbox, default 3s
+	BatchSize  int                           // Outbox rows of each drain, default 500
+	MaxRetries int                           // Max retries of failed rows, default 10
+	Backoff    func(retry int) time.Duration // Retry backoff, default exponential from 1s to 10m
+}
+
+// Database to elasticsearch sync by outbox table as durable queue, the
+// changed rows keys written into outbox by TxEnqueue() in the same
+// transaction of datas, then drained by worker to load rows as documents,
+// and bulk index or delete them, the failed rows retried with backoff.
+//
+// The outbox table must be created as follow (MySQL):
+//
+//	CREATE TABLE es_outbox (
+//		id      BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+//		tbl     VARCHAR(64)  NOT NULL,           -- Changed table name
+//		action  VARCHAR(16)  NOT NULL,           -- Change action
+//		rowkey  VARCHAR(128) NOT NULL,           -- Changed row key
+//		retries INT          NOT NULL DEFAULT 0, -- Failed retries
+//		next_at BIGINT       NOT NULL DEFAULT 0, -- Next drain time in milliseconds
+//		error   VARCHAR(255) NOT NULL DEFAULT '',
+//		INDEX idx_next (next_at)
+//	);
+//
+// # USAGE:
+//
+//	syncer := elastic.NewDBSync(elastic.GetEs(), mysql.NewTable("es_outbox"))
+//	syncer.Register("product", &elastic.SyncTable{Index: "products", Load: loadProducts, Scan: scanProductIDs})
+//	syncer.Start()
+//	defer syncer.Stop()
+//
+//	// write changes into outbox in the same transaction of datas.
+//	h := &Products{mysql.NewTable("product")}
+//	h.Trans(func(t *pd.Traner) error { return t.Exec(query, args...) },
+//		func(t *pd.Traner) error { return syncer.TxEnqueue(t, "product", elastic.ChangeUpdate, pid) })
+//
+// # WARNING:
+//   - Only TxEnqueue() is durable, the changes written out of transaction
+//     may lost when process crashed before Enqueue() called.
+//   - The documents always reloaded by keys, so the drain is idempotent and
+//     safe to run on multiple replicas, but may index the same rows twice.
+//   - The rows over max retries keep in outbox as dead letters, reset the
+//     retries to 0 to redrain them, or call Resync() to recover.
+type DBSync struct {
+	client Client
+	outbox *provider.TableProvider
+	opts   *SyncOptions
+	lock   sync.RWMutex
+	tables map[string]*SyncTable
+	wakeup chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Outbox row of changed row key.
+type outboxRow struct {
+	ID      int64
+	Table   string
+	Key     string
+	Retries int
+}
+
+// Create database sync with elastic client and outbox table provider.
+func NewDBSync(e Client, outbox *provider.TableProvider, opts ...*SyncOptions) *DBSync {
+	opt := &SyncOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		*opt = *opts[0]
+	}
+	if opt.Interval <= 0 {
+		opt.Interval = 3 * time.Second
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 500
+	}
+	if opt.MaxRetries <= 0 {
+		opt.MaxRetries = 10
+	}
+	if opt.Backoff == nil {
+		opt.Backoff = func(retry int) time.Duration {
+			return min(time.Second<<min(retry, 10), 10*time.Minute)
+		}
+	}
+
+	return &DBSync{
+		client: e, outbox: outbox, opts: opt,
+		tables: make(map[string]*SyncTable), wakeup: make(chan struct{}, 1),
+	}
+}
+
+// Register table sync config.
+func (s *DBSync) Register(table string, st *SyncTable) *DBSync {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tables[table] = st
+	return s
+}
+
+// Enqueue the changed rows keys into outbox in a new transaction, and
+// wakeup worker to drain, use TxEnqueue() to commit with datas together.
+func (s *DBSync) Enqueue(table, action string, keys ...any) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := s.outbox.Trans(func(t *pd.Traner) error {
+		return s.TxEnqueue(t, table, action, keys...)
+	})
+	if err == nil {
+		s.notify()
+	}
+	return err
+}
+
+// Enqueue the changed rows keys into outbox in the transaction, so the
+// changes and datas committed or rollbacked together, the action should be
+// one of ChangeInsert, ChangeUpdate, ChangeDelete.
+func (s *DBSync) TxEnqueue(t *pd.Traner, table, action string, keys ...any) error {
+	for _, key := range keys {
+		query, args := s.outbox.Inserter().Values(pd.KValues{
+			"tbl": table, "action": action, "rowkey": fmt.Sprint(key),
+		}).Build()
+		if err := t.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start worker to drain outbox periodically.
+func (s *DBSync) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel, s.done = cancel, make(chan struct{})
+	go s.run(ctx, s.done)
+}
+
+// Stop worker and wait the draining batch finished.
+func (s *DBSync) Stop() {
+	s.lock.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.lock.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Drain a batch of due rows from outbox, and return the drained count.
+func (s *DBSync) Drain(ctx context.Context) (int, error) {
+	rows := []*outboxRow{}
+	err := s.outbox.Querier().Tags("id", "tbl", "rowkey", "retries").
+		Wheres(pd.Wheres{"next_at<=?": time.Now().UnixMilli(), "retries<?": s.opts.MaxRetries}).
+		OrderBy("id", false).Limit(s.opts.BatchSize).
+		Array(pd.NewCreator(&rows, func(iv *outboxRow) []any {
+			return []any{&iv.ID, &iv.Table, &iv.Key, &iv.Retries}
+		}))
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	tables := map[string][]*outboxRow{}
+	for _, row := range rows {
+		tables[row.Table] = append(tables[row.Table], row)
+	}
+
+	succeed := []any{}
+	for table, trows := range tables {
+		failed := s.syncRows(ctx, table, trows)
+		for _, row := range trows {
+			if err, ok := failed[row.Key]; ok {
+				s.retry(row, err)
+			} else {
+				succeed = append(succeed, row.ID)
+			}
+		}
+	}
+
+	if len(succeed) > 0 {
+		if err := s.outbox.Deleter().WhereIn("id", succeed).Exec(); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), nil
+}
+
+// Full resync table rows into index by the Scan and Load functions, then
+// delete the documents of index which rows unexist any more.
+func (s *DBSync) Resync(ctx context.Context, table string) error {
+	st := s.table(table)
+	if st == nil || st.Scan == nil {
+		return invar.ErrNotSupport
+	}
+
+	for after := ""; ; {
+		keys, err := st.Scan(after, s.opts.BatchSize)
+		if err != nil {
+			return err
+		} else if len(keys) == 0 {
+			break
+		}
+
+		if failed := s.syncKeys(ctx, st, keys, false); len(failed) > 0 {
+			for key, err := range failed {
+				return fmt.Errorf("resync %s row %s failed: %w", table, key, err)
+			}
+		}
+		after = keys[len(keys)-1]
+	}
+
+	sb := NewSearch().NoSource()
+	opts := &IterOptions{BatchSize: s.opts.BatchSize}
+	for hits, err := range Iterate[map[string]any](ctx, s.client, st.Index, sb, opts) {
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(hits))
+		for _, hit := range hits {
+			keys = append(keys, hit.ID)
+		}
+		if failed := s.syncKeys(ctx, st, keys, true); len(failed) > 0 {
+			for key, err := range failed {
+				return fmt.Errorf("resync %s doc %s failed: %w", table, key, err)
+			}
+		}
+	}
+
+	esclog.I("Resynced table", table, "into", st.Index)
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Run worker to drain outbox when notified or periodically.
+func (s *DBSync) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			cnt, err := s.Drain(ctx)
+			if err != nil {
+				esclog.E("Drain outbox, err:", err)
+				break
+			} else if cnt < s.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wakeup:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wakeup worker to drain outbox.
+func (s *DBSync) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Return the registered table sync config.
+func (s *DBSync) table(table string) *SyncTable {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.tables[table]
+}
+
+// Sync the outbox rows of table, and return the failed keys.
+func (s *DBSync) syncRows(ctx context.Context, table string, rows []*outboxRow) map[string]error {
+	keys, exists := []string{}, map[string]bool{}
+	for _, row := range rows {
+		if !exists[row.Key] {
+			keys, exists[row.Key] = append(keys, row.Key), true
+		}
+	}
+
+	st := s.table(table)
+	if st == nil {
+		return failAll(keys, errors.New("unregistered sync table "+table))
+	}
+	return s.syncKeys(ctx, st, keys, false)
+}
+
+// Load documents by keys, then index the exist documents and delete the
+// missing keys, or only delete the missing keys when purge, it return the
+// failed keys.
+func (s *DBSync) syncKeys(ctx context.Context, st *SyncTable, keys []string, purge bool) map[string]error {
+	docs, err := st.Load(keys)
+	if err != nil {
+		return failAll(keys, err)
+	}
+
+	failed, lock := map[string]error{}, sync.Mutex{}
+	bi, err := s.client.NewBulkIndexer(&BulkOptions{
+		Workers: 1, FlushCount: len(keys) + 1, FlushInterval: time.Hour,
+		OnError: func(item *BulkItem, err error) {
+			if berr, ok := err.(*BulkError); ok && item.Action == BulkDelete && berr.Status == http.StatusNotFound {
+				return // document already deleted.
+			}
+			lock.Lock()
+			failed[item.DocID] = err
+			lock.Unlock()
+		},
+	})
+	if err != nil {
+		return failAll(keys, err)
+	}
+
+	for _, key := range keys {
+		if doc, ok := docs[key]; ok && doc != nil {
+			if purge {
+				continue
+			}
+			err = bi.Add(ctx, &BulkItem{Action: BulkIndex, Index: st.Index, DocID: key, Doc: doc})
+		} else {
+			err = bi.Add(ctx, &BulkItem{Action: BulkDelete, Index: st.Index, DocID: key})
+		}
+		if err != nil {
+			lock.Lock() // the worker may flush and report errors.
+			failed[key] = err
+			lock.Unlock()
+		}
+	}
+
+	if err := bi.Close(ctx); err != nil {
+		return failAll(keys, err)
+	}
+	return failed
+}
+
+// Update outbox row retries and next drain time.
+func (s *DBSync) retry(row *outboxRow, err error) {
+	reason := err.Error()
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+
+	next := time.Now().Add(s.opts.Backoff(row.Retries)).UnixMilli()
+	if e := s.outbox.Updater().Values(pd.KValues{
+		"retries": row.Retries + 1, "next_at": next, "error": reason,
+	}).Wheres(pd.Wheres{"id=?": row.ID}).Exec(); e != nil {
+		esclog.E("Update outbox row", row.ID, "err:", e)
+	}
+
+	if row.Retries+1 >= s.opts.MaxRetries {
+		esclog.E("Sync", row.Table, "row", row.Key, "dead after", row.Retries+1, "retries, err:", err)
+	}
+}
+
+// Return all keys failed with the error.
+func failAll(keys []string, err error) map[string]error {
+	failed := make(map[string]error, len(keys))
+	for _, key := range keys {
+		failed[key] = err
+	}
+	return failed
+}
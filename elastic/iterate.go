@@ -0,0 +1,364 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Options of iterate search results.
+type IterOptions struct {
+	BatchSize int           // Hits of each batch, default 1000
+	KeepAlive time.Duration // Keep alive of point in time or scroll context, default 1 minute
+	UseScroll bool          // Force use scroll api, default use point in time and fallback to scroll when unsupport
+}
+
+// Iterate all hits matched by search builder as batches, it use point in
+// time and search_after to page deeply over 10,000 hits, and fallback to
+// scroll api when point in time unsupported. The point in time or scroll
+// context released automatically when iteration finished, broken or the
+// context canceled.
+//
+// # USAGE:
+//
+//	sb := elastic.NewSearch().Query(elastic.Term("status", 1))
+//	for hits, err := range elastic.Iterate[Product](ctx, elastic.GetClient(), "products", sb) {
+//		if err != nil {
+//			return err
+//		}
+//		export(hits)
+//	}
+//
+// # WARNING:
+//   - The from, search_after and aggregations of search builder will be ignored.
+func Iterate[T any](ctx context.Context, c Client, index string, sb *SearchBuilder, opts ...*IterOptions) iter.Seq2[[]*Hit[T], error] {
+	opt := &IterOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 1000
+	}
+	if opt.KeepAlive <= 0 {
+		opt.KeepAlive = time.Minute
+	}
+
+	return func(yield func([]*Hit[T], error) bool) {
+		if c == nil {
+			yield(nil, invar.ErrInvalidClient)
+			return
+		}
+
+		var next func() (*Response, error)
+		if !opt.UseScroll {
+			pitid, err := c.OpenPIT(ctx, index, opt.KeepAlive)
+			if err == nil {
+				pit := &pitid
+				defer func() { closePIT(c, *pit) }()
+				next = pitPager(ctx, c, pit, sb, opt)
+			} else if err == invar.ErrInvalidClient {
+				yield(nil, err)
+				return
+			} else if ctx.Err() != nil {
+				yield(nil, ctx.Err())
+				return
+			} else {
+				esclog.W("Open point in time, err:", err, ", fallback to scroll")
+			}
+		}
+
+		if next == nil {
+			scrollid := new(string)
+			defer func() { clearScroll(c, *scrollid) }()
+			next = scrollPager(ctx, c, index, scrollid, sb, opt)
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			resp, err := next()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			rst, err := DecodeHits[T](resp)
+			if err != nil {
+				yield(nil, err)
+				return
+			} else if len(rst.Hits) == 0 || !yield(rst.Hits, nil) {
+				return
+			} else if len(rst.Hits) < opt.BatchSize {
+				return
+			}
+		}
+	}
+}
+
+// Iterate all hits matched by search builder as batches by channel, the
+// error channel output one error at most and closed when iteration finished.
+//
+//	batches, errs := elastic.IterateChan[Product](ctx, elastic.GetClient(), "products", sb)
+//	for hits := range batches {
+//		export(hits)
+//	}
+//	if err := <-errs; err != nil {
+//		logger.E("Export products, err:", err)
+//	}
+//
+// # WARNING:
+//   - Cancel the context to stop iteration when break out of the batches loop.
+func IterateChan[T any](ctx context.Context, c Client, index string, sb *SearchBuilder, opts ...*IterOptions) (<-chan []*Hit[T], <-chan error) {
+	batches, errs := make(chan []*Hit[T]), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(batches)
+
+		for hits, err := range Iterate[T](ctx, c, index, sb, opts...) {
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case batches <- hits:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return batches, errs
+}
+
+// Open point in time of index and return the pit id.
+func (e *ESClient) OpenPIT(ctx context.Context, index string, keepalive time.Duration) (string, error) {
+	if e.Conn == nil {
+		return "", invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.OpenPointInTime([]string{index}, timeUnit(keepalive),
+		e.Conn.OpenPointInTime.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	pit := struct {
+		ID string `json:"id"`
+	}{}
+	if err := decodeResp(res, &pit); err != nil {
+		return "", err
+	} else if pit.ID == "" {
+		return "", invar.ErrNotFound
+	}
+	return pit.ID, nil
+}
+
+// Close point in time.
+func (e *ESClient) ClosePIT(ctx context.Context, pitid string) error {
+	if e.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	body, _ := json.Marshal(map[string]string{"id": pitid})
+	res, err := e.Conn.ClosePointInTime(e.Conn.ClosePointInTime.WithContext(ctx),
+		e.Conn.ClosePointInTime.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return err
+	}
+	return respError(res)
+}
+
+// Search by point in time body without index, the body must contain pit
+// as {"pit": {"id": pitid, "keep_alive": "1m"}}.
+func (e *ESClient) SearchPIT(ctx context.Context, body map[string]any) (*Response, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.Search(e.Conn.Search.WithContext(ctx),
+		e.Conn.Search.WithBody(jsonReader(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	if err := decodeResp(res, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Search the first page by scroll api, and return the response with the
+// scroll id for Scroll() the next pages.
+func (e *ESClient) OpenScroll(ctx context.Context, index string, body map[string]any, keepalive time.Duration) (*Response, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.Search(e.Conn.Search.WithContext(ctx),
+		e.Conn.Search.WithIndex(index), e.Conn.Search.WithScroll(keepalive),
+		e.Conn.Search.WithBody(jsonReader(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	if err := decodeResp(res, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Return the next page of scroll search.
+func (e *ESClient) Scroll(ctx context.Context, scrollid string, keepalive time.Duration) (*Response, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+
+	params := map[string]string{"scroll_id": scrollid, "scroll": timeUnit(keepalive)}
+	res, err := e.Conn.Scroll(e.Conn.Scroll.WithContext(ctx),
+		e.Conn.Scroll.WithBody(jsonReader(params)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	if err := decodeResp(res, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Clear scroll context.
+func (e *ESClient) ClearScroll(ctx context.Context, scrollid string) error {
+	if e.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.ClearScroll(e.Conn.ClearScroll.WithContext(ctx),
+		e.Conn.ClearScroll.WithScrollID(scrollid))
+	if err != nil {
+		return err
+	}
+	return respError(res)
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Close point in time, it not use the iterate context that maybe canceled.
+func closePIT(c Client, pitid string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := c.ClosePIT(ctx, pitid); err != nil {
+		esclog.W("Close point in time, err:", err)
+	}
+}
+
+// Clear scroll context, it not use the iterate context that maybe canceled.
+func clearScroll(c Client, scrollid string) {
+	if scrollid == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := c.ClearScroll(ctx, scrollid); err != nil {
+		esclog.W("Clear scroll, err:", err)
+	}
+}
+
+// Return the pager of point in time search, it update the pit id and
+// search_after values after each page.
+func pitPager(ctx context.Context, c Client, pitid *string, sb *SearchBuilder, opt *IterOptions) func() (*Response, error) {
+	body := iterBody(sb, opt.BatchSize, "_shard_doc")
+	keepalive := timeUnit(opt.KeepAlive)
+
+	return func() (*Response, error) {
+		body["pit"] = map[string]string{"id": *pitid, "keep_alive": keepalive}
+		resp, err := c.SearchPIT(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.PitID != "" {
+			*pitid = resp.PitID
+		}
+		if hits := resp.Hits; hits != nil && len(hits.Hits) > 0 {
+			body["search_after"] = hits.Hits[len(hits.Hits)-1].Sort
+		}
+		return resp, nil
+	}
+}
+
+// Return the pager of scroll search, it output scroll id for clear.
+func scrollPager(ctx context.Context, c Client, index string, scrollid *string, sb *SearchBuilder, opt *IterOptions) func() (*Response, error) {
+	body := iterBody(sb, opt.BatchSize, "_doc")
+
+	return func() (*Response, error) {
+		var resp *Response
+		var err error
+		if *scrollid == "" {
+			resp, err = c.OpenScroll(ctx, index, body, opt.KeepAlive)
+		} else {
+			resp, err = c.Scroll(ctx, *scrollid, opt.KeepAlive)
+		}
+		if err != nil {
+			return nil, err
+		} else if resp.ScrollID != "" {
+			*scrollid = resp.ScrollID
+		}
+		return resp, nil
+	}
+}
+
+// Return the iterate search body of builder, it remove the from and
+// search_after, and sort by the given field when builder unsorted.
+func iterBody(sb *SearchBuilder, size int, sortby string) map[string]any {
+	if sb == nil {
+		sb = NewSearch()
+	}
+
+	body := sb.Body()
+	delete(body, "from")
+	delete(body, "search_after")
+	delete(body, "aggs")
+	body["size"] = size
+	if _, ok := body["sort"]; !ok {
+		body["sort"] = []string{sortby}
+	}
+	return body
+}
+
+// Return elasticsearch time unit string in milliseconds, such as '60000ms'.
+func timeUnit(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// Return json reader of value.
+func jsonReader(value any) *bytes.Reader {
+	buf, _ := json.Marshal(value)
+	return bytes.NewReader(buf)
+}
@@ -0,0 +1,1546 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// In-memory elasticsearch client for unit tests, it store documents in
+// memory and support a useful subset of search features:
+//
+//   - Queries: match_all, match, match_phrase, multi_match, term, terms,
+//     range, exists, ids, prefix, nested and bool.
+//   - Sort by fields or _score, from and size pagination, search_after.
+//   - Aggregations: terms, date_histogram, filter, avg, sum, min, max,
+//     value_count, cardinality.
+//   - Bulk indexer to index, create, update and delete documents.
+//   - Point in time and scroll to iterate, but not keep the documents snapshot.
+//
+// The text fields analyzed by a simple standard analyzer, it lowercase and
+// split words by none letters or digits, and each CJK char as one token.
+// The unmapped string fields analyzed as text with '.keyword' sub field as
+// dynamic mapping of elasticsearch.
+//
+// # USAGE:
+//
+//	mc := elastic.NewMemClient()
+//	mc.SetupIndexs(map[string]string{"products": productMapping})
+//	elastic.UseClient(mc)
+//	defer elastic.UseClient(nil)
+//
+//	mc.CreateIndexDoc("products", &Product{Title: "Red Phone", Price: 99}, "p1")
+//	sb := elastic.NewSearch().Query(elastic.Match("title", "phone"))
+//	rst, _ := elastic.Search[Product](ctx, mc, "products", sb)
+//
+// # WARNING:
+//   - The scores only count matched tokens, it not the BM25 scores of elasticsearch.
+//   - The unsupported queries and aggregations return invar.ErrNotSupport.
+type MemClient struct {
+	lock    sync.RWMutex
+	indexs  map[string]*memIndex
+	seq     int64
+	pits    map[string]string     // Index names of opened point in times
+	scrolls map[string]*memScroll // Opened scroll searches
+}
+
+// Scroll search state, it search again for each page.
+type memScroll struct {
+	index string
+	body  map[string]any
+	from  int
+}
+
+// Index of memory client.
+type memIndex struct {
+	fields map[string]*memField      // Mapped fields by dotted path
+	docs   map[string]map[string]any // Documents by id
+	seqs   map[string]int64          // Indexed sequence of documents
+}
+
+// Mapped field type and the source path of multi field.
+type memField struct {
+	path string // Source path of field value
+	kind string // Mapping type, such as text, keyword, long
+}
+
+// Matched document of search.
+type memHit struct {
+	index string
+	id    string
+	doc   map[string]any
+	seq   int64
+	score float64
+	sorts []any
+}
+
+// Interval of date histogram aggregation.
+type memInterval struct {
+	fixed time.Duration // Fixed interval, or 0 for calendar unit
+	unit  string        // Calendar unit, such as day, month
+}
+
+// Sort field and order.
+type memSort struct {
+	field string
+	desc  bool
+}
+
+var _ Client = (*MemClient)(nil)
+
+// Create in-memory elasticsearch client.
+func NewMemClient() *MemClient {
+	return &MemClient{
+		indexs: make(map[string]*memIndex),
+		pits:   make(map[string]string), scrolls: make(map[string]*memScroll),
+	}
+}
+
+// Setup search indexs with mapping if the index unexist.
+func (m *MemClient) SetupIndexs(indexs map[string]string) error {
+	for index, mapping := range indexs {
+		if exist, _ := m.IsExistIndex([]string{index}); !exist {
+			if err := m.CreateIndexMapping(index, mapping); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Create the new index with mapping, it return invar.ErrDupData when index exist.
+func (m *MemClient) CreateIndexMapping(index, mapping string) error {
+	fields, err := parseMapping(mapping)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.indexs[index]; ok {
+		return invar.ErrDupData
+	}
+	m.indexs[index] = newMemIndex(fields)
+	return nil
+}
+
+// Update the indexs mapping to add new fields.
+func (m *MemClient) UpdateIndexMapping(index []string, mapping string) error {
+	fields, err := parseMapping(mapping)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, name := range index {
+		idx, ok := m.indexs[name]
+		if !ok {
+			return invar.ErrNotFound
+		}
+		for path, field := range fields {
+			idx.fields[path] = field
+		}
+	}
+	return nil
+}
+
+// Check indexs whether all exist.
+func (m *MemClient) IsExistIndex(index []string) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, name := range index {
+		if _, ok := m.indexs[name]; !ok {
+			return false, nil
+		}
+	}
+	return len(index) > 0, nil
+}
+
+// Create or replace doc, it will auto create index when index unexist.
+func (m *MemClient) CreateIndexDoc(index string, doc any, docid ...string) error {
+	source, err := toSource(doc)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	idx, ok := m.indexs[index]
+	if !ok {
+		idx = newMemIndex(nil)
+		m.indexs[index] = idx
+	}
+
+	m.seq++
+	id := strconv.FormatInt(m.seq, 10)
+	if len(docid) > 0 && docid[0] != "" {
+		id = docid[0]
+	}
+	if _, exist := idx.docs[id]; !exist {
+		idx.seqs[id] = m.seq
+	}
+	idx.docs[id] = source
+	return nil
+}
+
+// Update the specified fields of doc, the doc string format as {"doc": {"field": value}}.
+func (m *MemClient) UpdateIndexDoc(index, docid, doc string) error {
+	update := struct {
+		Doc map[string]any `json:"doc"`
+	}{}
+	if err := json.Unmarshal([]byte(doc), &update); err != nil || update.Doc == nil {
+		return invar.ErrInvalidData
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	source := m.docOf(index, docid)
+	if source == nil {
+		return invar.ErrNotFound
+	}
+	mergeSource(source, update.Doc)
+	return nil
+}
+
+// Return the source of doc, it return invar.ErrNotFound when doc unexist.
+func (m *MemClient) GetIndexDoc(index, docid string) (json.RawMessage, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	source := m.docOf(index, docid)
+	if source == nil {
+		return nil, invar.ErrNotFound
+	}
+	return json.Marshal(source)
+}
+
+// Delete the doc, it return invar.ErrNotFound when doc unexist.
+func (m *MemClient) DeleteIndexDoc(index, docid string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.docOf(index, docid) == nil {
+		return invar.ErrNotFound
+	}
+
+	idx := m.indexs[index]
+	delete(idx.docs, docid)
+	delete(idx.seqs, docid)
+	return nil
+}
+
+// Search docs by query body, and set page as from offset, limit as size.
+func (m *MemClient) SearchIndex(index, query string, page int, limit ...int) (*Response, error) {
+	body := map[string]any{}
+	if strings.TrimSpace(query) != "" {
+		if err := json.Unmarshal([]byte(query), &body); err != nil {
+			return nil, invar.ErrInvalidData
+		}
+	}
+
+	body["from"] = float64(page)
+	body["size"] = float64(10)
+	if len(limit) > 0 {
+		body["size"] = float64(limit[0])
+	}
+	return m.search(index, body)
+}
+
+// Search by builder and return response.
+func (m *MemClient) DoSearch(ctx context.Context, index string, sb *SearchBuilder) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{}
+	if sb != nil {
+		buf, err := json.Marshal(sb)
+		if err != nil {
+			return nil, err
+		} else if err := json.Unmarshal(buf, &body); err != nil {
+			return nil, err
+		}
+	}
+	return m.search(index, body)
+}
+
+// Open point in time of index, the point in time not keep the snapshot of
+// documents, and the keepalive ignored.
+func (m *MemClient) OpenPIT(ctx context.Context, index string, keepalive time.Duration) (string, error) {
+	if exist, _ := m.IsExistIndex([]string{index}); !exist {
+		return "", invar.ErrNotFound
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.seq++
+	pitid := "pit-" + strconv.FormatInt(m.seq, 10)
+	m.pits[pitid] = index
+	return pitid, nil
+}
+
+// Close point in time, it return invar.ErrNotFound when unexist.
+func (m *MemClient) ClosePIT(ctx context.Context, pitid string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.pits[pitid]; !ok {
+		return invar.ErrNotFound
+	}
+	delete(m.pits, pitid)
+	return nil
+}
+
+// Search by point in time body, the '_shard_doc' tiebreaker appended to
+// sorts when missing same as elasticsearch.
+func (m *MemClient) SearchPIT(ctx context.Context, body map[string]any) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	query, err := normalizeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	pit, _ := query["pit"].(map[string]any)
+	pitid, _ := pit["id"].(string)
+	m.lock.RLock()
+	index, ok := m.pits[pitid]
+	m.lock.RUnlock()
+	if !ok {
+		return nil, invar.ErrNotFound
+	}
+	delete(query, "pit")
+
+	if sorts := clauseList(query["sort"]); !slices.Contains(sorts, any("_shard_doc")) {
+		if len(sorts) == 0 {
+			sorts = []any{map[string]any{"_score": "desc"}}
+		}
+		query["sort"] = append(sorts, "_shard_doc")
+	}
+
+	resp, err := m.search(index, query)
+	if err != nil {
+		return nil, err
+	}
+	resp.PitID = pitid
+	return resp, nil
+}
+
+// Search the first page and open scroll, the scroll not keep the snapshot
+// of documents, and the keepalive ignored.
+func (m *MemClient) OpenScroll(ctx context.Context, index string, body map[string]any, keepalive time.Duration) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	query, err := normalizeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	delete(query, "from")
+
+	m.lock.Lock()
+	m.seq++
+	scrollid := "scroll-" + strconv.FormatInt(m.seq, 10)
+	m.scrolls[scrollid] = &memScroll{index: index, body: query}
+	m.lock.Unlock()
+	return m.Scroll(ctx, scrollid, keepalive)
+}
+
+// Return the next page of scroll search.
+func (m *MemClient) Scroll(ctx context.Context, scrollid string, keepalive time.Duration) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	scroll, ok := m.scrolls[scrollid]
+	if !ok {
+		m.lock.Unlock()
+		return nil, invar.ErrNotFound
+	}
+
+	size := intValue(scroll.body["size"], 10)
+	scroll.body["from"], scroll.from = float64(scroll.from), scroll.from+size
+	body := maps.Clone(scroll.body)
+	m.lock.Unlock()
+
+	resp, err := m.search(scroll.index, body)
+	if err != nil {
+		return nil, err
+	}
+	resp.ScrollID = scrollid
+	return resp, nil
+}
+
+// Clear scroll, it return invar.ErrNotFound when unexist.
+func (m *MemClient) ClearScroll(ctx context.Context, scrollid string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.scrolls[scrollid]; !ok {
+		return invar.ErrNotFound
+	}
+	delete(m.scrolls, scrollid)
+	return nil
+}
+
+// Create and start bulk indexer, the bulk actions applied to memory docs.
+func (m *MemClient) NewBulkIndexer(opts *BulkOptions) (*BulkIndexer, error) {
+	return newBulkIndexer(m.bulk, opts), nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Bulk Executor                                                   */
+/* ------------------------------------------------------------------- */
+
+// Apply the bulk actions of ndjson body, and return the items results as
+// the bulk response of elasticsearch.
+func (m *MemClient) bulk(body io.Reader, refresh string) (*esapi.Response, error) {
+	resp := &bulkResp{Items: []map[string]*bulkRespItem{}}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		meta := map[string]*bulkRespItem{}
+		if err := json.Unmarshal(scanner.Bytes(), &meta); err != nil || len(meta) != 1 {
+			return nil, invar.ErrInvalidData
+		}
+
+		for action, item := range meta {
+			var doc []byte
+			if action != BulkDelete {
+				if !scanner.Scan() {
+					return nil, invar.ErrInvalidData
+				}
+				doc = bytes.Clone(scanner.Bytes())
+			}
+
+			m.bulkItem(action, item, doc)
+			resp.Errors = resp.Errors || item.Error != nil
+			resp.Items = append(resp.Items, map[string]*bulkRespItem{action: item})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &esapi.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+// Apply the bulk action and set the result status and error of item.
+func (m *MemClient) bulkItem(action string, item *bulkRespItem, doc []byte) {
+	var err error
+	item.Status = http.StatusOK
+	switch action {
+	case BulkCreate:
+		if _, e := m.GetIndexDoc(item.Index, item.ID); item.ID != "" && e == nil {
+			item.Status = http.StatusConflict
+			item.Error = &Reason{Type: "version_conflict_engine_exception", Reason: "document already exists"}
+			return
+		}
+		fallthrough
+	case BulkIndex:
+		item.Status, err = http.StatusCreated, m.CreateIndexDoc(item.Index, doc, item.ID)
+	case BulkUpdate:
+		err = m.UpdateIndexDoc(item.Index, item.ID, string(doc))
+	case BulkDelete:
+		err = m.DeleteIndexDoc(item.Index, item.ID)
+	default:
+		err = invar.ErrNotSupport
+	}
+
+	switch {
+	case err == invar.ErrNotFound:
+		item.Status, item.Error = http.StatusNotFound, &Reason{Type: "document_missing_exception", Reason: "document missing"}
+	case err != nil:
+		item.Status, item.Error = http.StatusBadRequest, &Reason{Type: "illegal_argument_exception", Reason: err.Error()}
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Search Executor                                                 */
+/* ------------------------------------------------------------------- */
+
+// Search the indexs by normalized json body.
+func (m *MemClient) search(index string, body map[string]any) (*Response, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	indexs, err := m.matchIndexs(index)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []*memHit{}
+	for name, idx := range indexs {
+		for id, doc := range idx.docs {
+			matched, score, err := idx.match(id, doc, body["query"])
+			if err != nil {
+				return nil, err
+			} else if matched {
+				hits = append(hits, &memHit{index: name, id: id, doc: doc, seq: idx.seqs[id], score: score})
+			}
+		}
+	}
+
+	sorts, err := parseSorts(body["sort"])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		for _, st := range sorts {
+			hit.sorts = append(hit.sorts, indexs[hit.index].sortValue(hit, st))
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if c := compareSorts(hits[i].sorts, hits[j].sorts, sorts); c != 0 {
+			return c < 0
+		} else if hits[i].seq != hits[j].seq {
+			return hits[i].seq < hits[j].seq // keep ties in indexed order for paging
+		}
+		return hits[i].index < hits[j].index
+	})
+
+	resp := map[string]any{"took": 0, "timed_out": false}
+	if aggs, ok := body["aggs"].(map[string]any); ok {
+		results := map[string]any{}
+		for name, agg := range aggs {
+			rst, err := aggregate(indexs, hits, agg)
+			if err != nil {
+				return nil, err
+			}
+			results[name] = rst
+		}
+		resp["aggregations"] = results
+	}
+
+	total := len(hits)
+	if after, ok := body["search_after"].([]any); ok && len(after) > 0 {
+		i := sort.Search(len(hits), func(i int) bool { return compareSorts(hits[i].sorts, after, sorts) > 0 })
+		hits = hits[i:]
+	}
+
+	from, size := intValue(body["from"], 0), intValue(body["size"], 10)
+	hits = hits[min(max(from, 0), len(hits)):]
+	hits = hits[:min(max(size, 0), len(hits))]
+
+	outs, explicit := []any{}, body["sort"] != nil
+	for _, hit := range hits {
+		out := map[string]any{"_index": hit.index, "_id": hit.id, "_score": hit.score, "_source": filterSource(hit.doc, body["_source"])}
+		if explicit {
+			out["sort"] = hit.sorts
+		}
+		outs = append(outs, out)
+	}
+	resp["hits"] = map[string]any{"total": map[string]any{"value": total, "relation": "eq"}, "hits": outs}
+
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	rst := &Response{}
+	if err := json.Unmarshal(buf, rst); err != nil {
+		return nil, err
+	}
+	return rst, nil
+}
+
+// Return the indexs matched by index names, the names separated by comma,
+// and support '*' wildcard suffix.
+func (m *MemClient) matchIndexs(index string) (map[string]*memIndex, error) {
+	indexs := map[string]*memIndex{}
+	for _, name := range strings.Split(index, ",") {
+		if name = strings.TrimSpace(name); strings.HasSuffix(name, "*") {
+			for iname, idx := range m.indexs {
+				if strings.HasPrefix(iname, strings.TrimSuffix(name, "*")) {
+					indexs[iname] = idx
+				}
+			}
+		} else if idx, ok := m.indexs[name]; ok {
+			indexs[name] = idx
+		} else {
+			return nil, invar.ErrNotFound
+		}
+	}
+	return indexs, nil
+}
+
+// Return the stored doc, or nil when unexist.
+func (m *MemClient) docOf(index, docid string) map[string]any {
+	if idx, ok := m.indexs[index]; ok {
+		return idx.docs[docid]
+	}
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Query Matchers                                                  */
+/* ------------------------------------------------------------------- */
+
+// Check whether doc matched the query and return the score.
+func (idx *memIndex) match(id string, doc map[string]any, query any) (bool, float64, error) {
+	if query == nil {
+		return true, 1, nil
+	}
+
+	clause, ok := query.(map[string]any)
+	if !ok || len(clause) != 1 {
+		return false, 0, invar.ErrInvalidData
+	}
+
+	for kind, value := range clause {
+		params, _ := value.(map[string]any)
+		switch kind {
+		case "match_all":
+			return true, 1, nil
+		case "bool":
+			return idx.matchBool(id, doc, params)
+		case "match", "match_phrase":
+			field, text, opts := fieldParam(params, "query")
+			score := idx.matchText(doc, field, text, kind == "match_phrase", opts["operator"] == "and")
+			return score > 0, score, nil
+		case "multi_match":
+			best := 0.0
+			fields, _ := params["fields"].([]any)
+			for _, field := range fields {
+				name, _, _ := strings.Cut(fmt.Sprint(field), "^")
+				best = max(best, idx.matchText(doc, name, params["query"], false, params["operator"] == "and"))
+			}
+			return best > 0, best, nil
+		case "term":
+			field, want, _ := fieldParam(params, "value")
+			return idx.matchTerm(doc, field, want), 1, nil
+		case "terms":
+			for field, wants := range params {
+				list, _ := wants.([]any)
+				for _, want := range list {
+					if idx.matchTerm(doc, field, want) {
+						return true, 1, nil
+					}
+				}
+			}
+			return false, 0, nil
+		case "range":
+			for field, bounds := range params {
+				if b, ok := bounds.(map[string]any); ok {
+					return idx.matchRange(doc, field, b), 1, nil
+				}
+			}
+			return false, 0, invar.ErrInvalidData
+		case "exists":
+			path, _ := idx.fieldOf(fmt.Sprint(params["field"]))
+			return len(fieldValues(doc, path)) > 0, 1, nil
+		case "ids":
+			values, _ := params["values"].([]any)
+			for _, value := range values {
+				if fmt.Sprint(value) == id {
+					return true, 1, nil
+				}
+			}
+			return false, 0, nil
+		case "prefix":
+			field, want, _ := fieldParam(params, "value")
+			path, _ := idx.fieldOf(field)
+			for _, value := range fieldValues(doc, path) {
+				if s, ok := value.(string); ok && strings.HasPrefix(s, fmt.Sprint(want)) {
+					return true, 1, nil
+				}
+			}
+			return false, 0, nil
+		case "nested":
+			path := fmt.Sprint(params["path"])
+			for _, item := range fieldValues(doc, path) {
+				matched, score, err := idx.match(id, nestDoc(path, item), params["query"])
+				if err != nil || matched {
+					return matched, score, err
+				}
+			}
+			return false, 0, nil
+		default:
+			return false, 0, fmt.Errorf("%w: %s query", invar.ErrNotSupport, kind)
+		}
+	}
+	return false, 0, nil
+}
+
+// Check bool query clauses, the should clauses required at least one when
+// without must and filter clauses.
+func (idx *memIndex) matchBool(id string, doc map[string]any, params map[string]any) (bool, float64, error) {
+	score := 0.0
+	for _, key := range []string{"must", "filter"} {
+		for _, query := range clauseList(params[key]) {
+			matched, s, err := idx.match(id, doc, query)
+			if err != nil || !matched {
+				return false, 0, err
+			} else if key == "must" {
+				score += s
+			}
+		}
+	}
+
+	for _, query := range clauseList(params["must_not"]) {
+		matched, _, err := idx.match(id, doc, query)
+		if err != nil || matched {
+			return false, 0, err
+		}
+	}
+
+	shoulds, minshould := clauseList(params["should"]), 0
+	if len(shoulds) > 0 && params["must"] == nil && params["filter"] == nil {
+		minshould = 1
+	}
+	if v, ok := params["minimum_should_match"]; ok {
+		minshould = minimumShould(v, len(shoulds))
+	}
+
+	matches := 0
+	for _, query := range shoulds {
+		matched, s, err := idx.match(id, doc, query)
+		if err != nil {
+			return false, 0, err
+		} else if matched {
+			matches, score = matches+1, score+s
+		}
+	}
+	if matches < minshould {
+		return false, 0, nil
+	}
+	return true, max(score, 1), nil
+}
+
+// Return the count of matched query tokens of text field, or 1 when the
+// keyword field value equal.
+func (idx *memIndex) matchText(doc map[string]any, field string, text any, phrase, and bool) float64 {
+	path, kind := idx.fieldOf(field)
+	values := fieldValues(doc, path)
+	if !isTextField(kind, values) {
+		for _, value := range values {
+			if equalValue(value, text) {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	words := analyze(fmt.Sprint(text))
+	if len(words) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for _, value := range values {
+		tokens := analyze(fmt.Sprint(value))
+		if phrase {
+			if containsPhrase(tokens, words) {
+				best = max(best, float64(len(words)))
+			}
+			continue
+		}
+
+		matched := 0
+		for _, word := range words {
+			if containsToken(tokens, word) {
+				matched++
+			}
+		}
+		if and && matched < len(words) {
+			continue
+		}
+		best = max(best, float64(matched))
+	}
+	return best
+}
+
+// Check whether field value exactly equal, the text field match by tokens.
+func (idx *memIndex) matchTerm(doc map[string]any, field string, want any) bool {
+	path, kind := idx.fieldOf(field)
+	values := fieldValues(doc, path)
+	text := isTextField(kind, values)
+	for _, value := range values {
+		if text {
+			if containsToken(analyze(fmt.Sprint(value)), fmt.Sprint(want)) {
+				return true
+			}
+		} else if equalValue(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether any field value in range bounds.
+func (idx *memIndex) matchRange(doc map[string]any, field string, bounds map[string]any) bool {
+	path, _ := idx.fieldOf(field)
+	for _, value := range fieldValues(doc, path) {
+		inrange := true
+		for op, bound := range bounds {
+			c, ok := compareValue(value, bound)
+			switch op {
+			case "gt":
+				inrange = inrange && ok && c > 0
+			case "gte":
+				inrange = inrange && ok && c >= 0
+			case "lt":
+				inrange = inrange && ok && c < 0
+			case "lte":
+				inrange = inrange && ok && c <= 0
+			}
+		}
+		if inrange {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the source path and mapping type of field, the '.keyword' sub field
+// of unmapped string field treated as keyword.
+func (idx *memIndex) fieldOf(field string) (string, string) {
+	if f, ok := idx.fields[field]; ok {
+		return f.path, f.kind
+	} else if path, ok := strings.CutSuffix(field, ".keyword"); ok {
+		return path, "keyword"
+	}
+	return field, ""
+}
+
+/* ------------------------------------------------------------------- */
+/* For Sorts And Aggregations                                          */
+/* ------------------------------------------------------------------- */
+
+// Return the sort value of hit, the min value for ascending and max value
+// for descending when field has multiple values.
+func (idx *memIndex) sortValue(hit *memHit, st *memSort) any {
+	switch st.field {
+	case "_score":
+		return hit.score
+	case "_doc", "_shard_doc":
+		return hit.seq
+	case "_id":
+		return hit.id
+	}
+
+	path, _ := idx.fieldOf(st.field)
+	var picked any
+	for _, value := range fieldValues(hit.doc, path) {
+		if c, ok := compareValue(value, picked); picked == nil || (ok && (c < 0) != st.desc) {
+			picked = value
+		}
+	}
+	return picked
+}
+
+// Parse sort fields, the default sort by score descending and indexed order.
+func parseSorts(value any) ([]*memSort, error) {
+	sorts := []*memSort{}
+	for _, item := range clauseList(value) {
+		switch s := item.(type) {
+		case string:
+			sorts = append(sorts, &memSort{field: s, desc: s == "_score"})
+		case map[string]any:
+			for field, order := range s {
+				if field == "_geo_distance" || field == "_script" {
+					return nil, fmt.Errorf("%w: %s sort", invar.ErrNotSupport, field)
+				} else if params, ok := order.(map[string]any); ok {
+					order = params["order"]
+				}
+				sorts = append(sorts, &memSort{field: field, desc: order == "desc"})
+			}
+		}
+	}
+
+	if len(sorts) == 0 {
+		sorts = []*memSort{{field: "_score", desc: true}, {field: "_doc"}}
+	}
+	return sorts, nil
+}
+
+// Compare sort values by orders, the nil values always sort last.
+func compareSorts(a, b []any, sorts []*memSort) int {
+	for i := 0; i < len(sorts) && i < len(a) && i < len(b); i++ {
+		if a[i] == nil || b[i] == nil {
+			if a[i] != nil {
+				return -1
+			} else if b[i] != nil {
+				return 1
+			}
+			continue
+		}
+
+		if c, _ := compareValue(a[i], b[i]); c != 0 {
+			if sorts[i].desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// Compute aggregation of matched hits.
+func aggregate(indexs map[string]*memIndex, hits []*memHit, agg any) (map[string]any, error) {
+	params, _ := agg.(map[string]any)
+	subs, _ := params["aggs"].(map[string]any)
+
+	for kind, value := range params {
+		if kind == "aggs" {
+			continue
+		}
+
+		opts, _ := value.(map[string]any)
+		switch kind {
+		case "terms":
+			return termsAgg(indexs, hits, opts, subs)
+		case "date_histogram":
+			return dateHistogramAgg(indexs, hits, opts, subs)
+		case "filter":
+			filtered := []*memHit{}
+			for _, hit := range hits {
+				matched, _, err := indexs[hit.index].match(hit.id, hit.doc, value)
+				if err != nil {
+					return nil, err
+				} else if matched {
+					filtered = append(filtered, hit)
+				}
+			}
+			return bucketAgg(indexs, filtered, map[string]any{}, subs)
+		case "avg", "sum", "min", "max", "value_count", "cardinality":
+			return metricAgg(indexs, hits, kind, fmt.Sprint(opts["field"])), nil
+		default:
+			return nil, fmt.Errorf("%w: %s aggregation", invar.ErrNotSupport, kind)
+		}
+	}
+	return nil, invar.ErrInvalidData
+}
+
+// Compute terms aggregation ordered by doc count descending.
+func termsAgg(indexs map[string]*memIndex, hits []*memHit, opts, subs map[string]any) (map[string]any, error) {
+	field, size := fmt.Sprint(opts["field"]), intValue(opts["size"], 10)
+	keys, groups := []any{}, map[string][]*memHit{}
+	for _, hit := range hits {
+		path, _ := indexs[hit.index].fieldOf(field)
+		seen := map[string]bool{}
+		for _, value := range fieldValues(hit.doc, path) {
+			key := fmt.Sprint(value)
+			if seen[key] {
+				continue
+			} else if _, ok := groups[key]; !ok {
+				keys = append(keys, value)
+			}
+			seen[key], groups[key] = true, append(groups[key], hit)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		ci, cj := len(groups[fmt.Sprint(keys[i])]), len(groups[fmt.Sprint(keys[j])])
+		if ci != cj {
+			return ci > cj
+		}
+		c, _ := compareValue(keys[i], keys[j])
+		return c < 0
+	})
+
+	buckets, others := []any{}, 0
+	for i, key := range keys {
+		group := groups[fmt.Sprint(key)]
+		if i >= size {
+			others += len(group)
+			continue
+		}
+
+		bucket, err := bucketAgg(indexs, group, map[string]any{"key": key}, subs)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return map[string]any{"doc_count_error_upper_bound": 0, "sum_other_doc_count": others, "buckets": buckets}, nil
+}
+
+// Compute date histogram aggregation ordered by bucket key ascending, the
+// empty buckets between the first and last keys filled when min_doc_count
+// is 0 as elasticsearch, the dates rounded in UTC.
+func dateHistogramAgg(indexs map[string]*memIndex, hits []*memHit, opts, subs map[string]any) (map[string]any, error) {
+	interval, err := parseInterval(opts)
+	if err != nil {
+		return nil, err
+	} else if zone, ok := opts["time_zone"]; ok && zone != "UTC" && zone != "Z" && zone != "+00:00" {
+		return nil, fmt.Errorf("%w: date_histogram time_zone %v", invar.ErrNotSupport, zone)
+	}
+
+	field, mincnt := fmt.Sprint(opts["field"]), intValue(opts["min_doc_count"], 0)
+	groups := map[int64][]*memHit{}
+	for _, hit := range hits {
+		path, _ := indexs[hit.index].fieldOf(field)
+		for _, value := range fieldValues(hit.doc, path) {
+			if date, ok := toDate(value); ok {
+				key := interval.round(date).UnixMilli()
+				groups[key] = append(groups[key], hit)
+			}
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(groups))
+	if mincnt == 0 && len(keys) > 1 {
+		first, last := time.UnixMilli(keys[0]).UTC(), keys[len(keys)-1]
+		keys = keys[:0]
+		for date := first; date.UnixMilli() <= last; date = interval.next(date) {
+			keys = append(keys, date.UnixMilli())
+		}
+	}
+
+	layout := dateLayout(opts["format"])
+	buckets := []any{}
+	for _, key := range keys {
+		if group := groups[key]; len(group) >= mincnt {
+			str := time.UnixMilli(key).UTC().Format(layout)
+			bucket, err := bucketAgg(indexs, group, map[string]any{"key_as_string": str, "key": key}, subs)
+			if err != nil {
+				return nil, err
+			}
+			buckets = append(buckets, bucket)
+		}
+	}
+	return map[string]any{"buckets": buckets}, nil
+}
+
+// Fill the doc count and sub aggregations into bucket.
+func bucketAgg(indexs map[string]*memIndex, hits []*memHit, bucket, subs map[string]any) (map[string]any, error) {
+	bucket["doc_count"] = len(hits)
+	for name, sub := range subs {
+		rst, err := aggregate(indexs, hits, sub)
+		if err != nil {
+			return nil, err
+		}
+		bucket[name] = rst
+	}
+	return bucket, nil
+}
+
+// Compute metric aggregation of number field, the cardinality and value_count
+// support any type values.
+func metricAgg(indexs map[string]*memIndex, hits []*memHit, kind, field string) map[string]any {
+	count, sum, minv, maxv := 0, 0.0, math.Inf(1), math.Inf(-1)
+	distinct := map[string]bool{}
+	for _, hit := range hits {
+		path, _ := indexs[hit.index].fieldOf(field)
+		for _, value := range fieldValues(hit.doc, path) {
+			count, distinct[fmt.Sprint(value)] = count+1, true
+			if num, ok := value.(float64); ok {
+				sum, minv, maxv = sum+num, math.Min(minv, num), math.Max(maxv, num)
+			}
+		}
+	}
+
+	switch kind {
+	case "value_count":
+		return map[string]any{"value": count}
+	case "cardinality":
+		return map[string]any{"value": len(distinct)}
+	case "sum":
+		return map[string]any{"value": sum}
+	}
+
+	if count == 0 {
+		return map[string]any{"value": nil}
+	}
+	value := map[string]float64{"avg": sum / float64(count), "min": minv, "max": maxv}[kind]
+	return map[string]any{"value": value}
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Parse the calendar_interval or fixed_interval of date histogram, the
+// calendar units support minute, hour, day, week, month, quarter and year.
+func parseInterval(opts map[string]any) (*memInterval, error) {
+	if unit, ok := opts["calendar_interval"].(string); ok {
+		units := map[string]string{
+			"1m": "minute", "1h": "hour", "1d": "day", "1w": "week", "1M": "month", "1q": "quarter", "1y": "year",
+		}
+		if name, ok := units[unit]; ok {
+			unit = name
+		}
+		if slices.Contains([]string{"minute", "hour", "day", "week", "month", "quarter", "year"}, unit) {
+			return &memInterval{unit: unit}, nil
+		}
+		return nil, fmt.Errorf("%w: calendar_interval %s", invar.ErrNotSupport, unit)
+	}
+
+	fixed, ok := opts["fixed_interval"].(string)
+	if !ok {
+		return nil, invar.ErrInvalidData
+	}
+
+	var duration time.Duration
+	if days, found := strings.CutSuffix(fixed, "d"); found {
+		cnt, err := strconv.Atoi(days)
+		if err != nil {
+			return nil, invar.ErrInvalidData
+		}
+		duration = time.Duration(cnt) * 24 * time.Hour
+	} else if d, err := time.ParseDuration(fixed); err != nil {
+		return nil, invar.ErrInvalidData
+	} else {
+		duration = d
+	}
+
+	if duration < time.Millisecond {
+		return nil, invar.ErrInvalidData
+	}
+	return &memInterval{fixed: duration}, nil
+}
+
+// Round down date to the start of interval.
+func (iv *memInterval) round(date time.Time) time.Time {
+	if iv.fixed > 0 {
+		ms, step := date.UnixMilli(), iv.fixed.Milliseconds()
+		return time.UnixMilli(ms - ((ms%step)+step)%step).UTC()
+	}
+
+	date = date.UTC()
+	y, m, d := date.Date()
+	switch iv.unit {
+	case "minute":
+		return date.Truncate(time.Minute)
+	case "hour":
+		return date.Truncate(time.Hour)
+	case "week": // week start from monday as elasticsearch
+		return time.Date(y, m, d-(int(date.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Return the start of next interval.
+func (iv *memInterval) next(date time.Time) time.Time {
+	switch {
+	case iv.fixed > 0:
+		return date.Add(iv.fixed)
+	case iv.unit == "minute":
+		return date.Add(time.Minute)
+	case iv.unit == "hour":
+		return date.Add(time.Hour)
+	case iv.unit == "week":
+		return date.AddDate(0, 0, 7)
+	case iv.unit == "month":
+		return date.AddDate(0, 1, 0)
+	case iv.unit == "quarter":
+		return date.AddDate(0, 3, 0)
+	case iv.unit == "year":
+		return date.AddDate(1, 0, 0)
+	}
+	return date.AddDate(0, 0, 1)
+}
+
+// Convert epoch milliseconds or date string to time.
+func toDate(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		return time.UnixMilli(int64(v)).UTC(), true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", time.DateOnly} {
+			if date, err := time.Parse(layout, v); err == nil {
+				return date.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Convert the simple java date format of aggregation to go layout, default
+// as strict_date_optional_time of elasticsearch.
+func dateLayout(format any) string {
+	if f, ok := format.(string); ok && f != "" {
+		return strings.NewReplacer(
+			"yyyy", "2006", "MM", "01", "dd", "02", "HH", "15", "mm", "04", "ss", "05", "SSS", "000",
+		).Replace(f)
+	}
+	return "2006-01-02T15:04:05.000Z"
+}
+
+// Create memory index with mapped fields.
+func newMemIndex(fields map[string]*memField) *memIndex {
+	if fields == nil {
+		fields = map[string]*memField{}
+	}
+	return &memIndex{fields: fields, docs: map[string]map[string]any{}, seqs: map[string]int64{}}
+}
+
+// Parse mapping properties as dotted fields, the mapping can be the index
+// body with 'mappings' or the put mapping body with 'properties'.
+func parseMapping(mapping string) (map[string]*memField, error) {
+	fields := map[string]*memField{}
+	if strings.TrimSpace(mapping) == "" {
+		return fields, nil
+	}
+
+	body := map[string]any{}
+	if err := json.Unmarshal([]byte(mapping), &body); err != nil {
+		return nil, invar.ErrInvalidData
+	}
+
+	if mappings, ok := body["mappings"].(map[string]any); ok {
+		body = mappings
+	}
+	if props, ok := body["properties"].(map[string]any); ok {
+		parseProperties("", props, fields)
+	}
+	return fields, nil
+}
+
+// Parse properties recursively into dotted fields.
+func parseProperties(prefix string, props map[string]any, fields map[string]*memField) {
+	for name, value := range props {
+		def, _ := value.(map[string]any)
+		path := prefix + name
+		if kind, ok := def["type"].(string); ok {
+			fields[path] = &memField{path: path, kind: kind}
+		}
+		if subs, ok := def["properties"].(map[string]any); ok {
+			parseProperties(path+".", subs, fields)
+		}
+		if multis, ok := def["fields"].(map[string]any); ok {
+			for sub, subdef := range multis {
+				if m, ok := subdef.(map[string]any); ok {
+					fields[path+"."+sub] = &memField{path: path, kind: fmt.Sprint(m["type"])}
+				}
+			}
+		}
+	}
+}
+
+// Convert doc to json object source.
+func toSource(doc any) (map[string]any, error) {
+	var buf []byte
+	switch v := doc.(type) {
+	case string:
+		buf = []byte(v)
+	case []byte:
+		buf = v
+	default:
+		var err error
+		if buf, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	source := map[string]any{}
+	if err := json.Unmarshal(buf, &source); err != nil {
+		return nil, invar.ErrInvalidData
+	}
+	return source, nil
+}
+
+// Merge the fields into source deeply.
+func mergeSource(source, fields map[string]any) {
+	for key, value := range fields {
+		if sub, ok := value.(map[string]any); ok {
+			if exist, ok := source[key].(map[string]any); ok {
+				mergeSource(exist, sub)
+				continue
+			}
+		}
+		source[key] = value
+	}
+}
+
+// Return the source with included fields, it return whole source when
+// not set fields, or empty source when set false.
+func filterSource(doc map[string]any, includes any) map[string]any {
+	switch v := includes.(type) {
+	case bool:
+		if !v {
+			return map[string]any{}
+		}
+	case []any:
+		out := map[string]any{}
+		for _, field := range v {
+			path := fmt.Sprint(field)
+			if values := fieldValues(doc, path); len(values) > 0 {
+				mergeSource(out, nestDoc(path, fieldValue(doc, path)))
+			}
+		}
+		return out
+	}
+	return doc
+}
+
+// Return the flatten values of dotted path, the arrays expanded.
+func fieldValues(doc map[string]any, path string) []any {
+	values := []any{}
+	var walk func(value any, keys []string)
+	walk = func(value any, keys []string) {
+		if arr, ok := value.([]any); ok {
+			for _, item := range arr {
+				walk(item, keys)
+			}
+			return
+		} else if len(keys) == 0 {
+			if value != nil {
+				values = append(values, value)
+			}
+			return
+		}
+
+		if obj, ok := value.(map[string]any); ok {
+			walk(obj[keys[0]], keys[1:])
+		}
+	}
+	walk(doc, strings.Split(path, "."))
+	return values
+}
+
+// Return the raw value of dotted path without expand arrays.
+func fieldValue(doc map[string]any, path string) any {
+	var value any = doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+// Wrap value as doc of dotted path.
+func nestDoc(path string, value any) map[string]any {
+	keys := strings.Split(path, ".")
+	for i := len(keys) - 1; i > 0; i-- {
+		value = map[string]any{keys[i]: value}
+	}
+	return map[string]any{keys[0]: value}
+}
+
+// Return the copy of search body normalized as json values.
+func normalizeBody(body map[string]any) (map[string]any, error) {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]any{}
+	if err := json.Unmarshal(buf, &query); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// Return clauses list of single clause or clauses array.
+func clauseList(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// Return the field and value of query params, it support short form as
+// {field: value} and long form as {field: {key: value, ...options}}.
+func fieldParam(params map[string]any, key string) (string, any, map[string]any) {
+	for field, value := range params {
+		if opts, ok := value.(map[string]any); ok {
+			return field, opts[key], opts
+		}
+		return field, value, map[string]any{}
+	}
+	return "", nil, map[string]any{}
+}
+
+// Return the minimum should match count of number or percent string.
+func minimumShould(value any, total int) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		if percent, ok := strings.CutSuffix(v, "%"); ok {
+			p, _ := strconv.Atoi(percent)
+			return total * p / 100
+		}
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// Check whether field analyzed as text, the unmapped string field treated as text.
+func isTextField(kind string, values []any) bool {
+	if kind != "" {
+		return kind == "text"
+	}
+	for _, value := range values {
+		if _, ok := value.(string); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze text to lowercase tokens, the CJK chars split as single tokens.
+func analyze(text string) []string {
+	tokens, word := []string{}, []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			tokens, word = append(tokens, string(word)), word[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Check whether tokens contain the word.
+func containsToken(tokens []string, word string) bool {
+	for _, token := range tokens {
+		if token == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether tokens contain the words in sequence.
+func containsPhrase(tokens, words []string) bool {
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		matched := true
+		for j, word := range words {
+			if tokens[i+j] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether values equal, the number string equal to number.
+func equalValue(a, b any) bool {
+	if c, ok := compareValue(a, b); ok {
+		return c == 0
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// Compare numbers, strings or bools, the number string compared as number.
+func compareValue(a, b any) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := toNumber(b); ok {
+			return cmpOrdered(av, bv), true
+		}
+	case int64:
+		if bv, ok := toNumber(b); ok {
+			return cmpOrdered(float64(av), bv), true
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		} else if bn, ok := toNumber(b); ok {
+			if an, err := strconv.ParseFloat(av, 64); err == nil {
+				return cmpOrdered(an, bn), true
+			}
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			return cmpOrdered(boolNumber(av), boolNumber(bv)), true
+		}
+	}
+	return 0, false
+}
+
+// Convert number or number string to float64.
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Compare two ordered numbers.
+func cmpOrdered(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Return 1 for true and 0 for false.
+func boolNumber(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Return the int value of json number, or default value.
+func intValue(value any, def int) int {
+	if n, ok := toNumber(value); ok {
+		return int(n)
+	}
+	return def
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/elastic, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Product document of memory client tests.
+type memProduct struct {
+	Title   string   `json:"title"`
+	Brand   string   `json:"brand"`
+	Tags    []string `json:"tags"`
+	Price   float64  `json:"price"`
+	Created string   `json:"created"`
+}
+
+// Mapping of products index.
+const memProductMapping = `{"mappings": {"properties": {
+	"title": {"type": "text"}, "brand": {"type": "keyword"}, "tags": {"type": "keyword"},
+	"price": {"type": "double"}, "created": {"type": "date"}
+}}}`
+
+// Create memory client with products, the products ordered by price
+// ascending are p4, p5, p2, p1, p3.
+func newMemProducts(t *testing.T) *MemClient {
+	mc := NewMemClient()
+	if err := mc.SetupIndexs(map[string]string{"products": memProductMapping}); err != nil {
+		t.Fatal("Setup index, err:", err)
+	}
+
+	products := map[string]*memProduct{
+		"p1": {"Red Phone Pro", "apple", []string{"phone", "red"}, 999, "2024-01-15T10:00:00Z"},
+		"p2": {"Blue Phone", "xiaomi", []string{"phone", "blue"}, 299, "2024-01-20T08:00:00Z"},
+		"p3": {"Red Laptop", "apple", []string{"laptop", "red"}, 1999, "2024-03-02T12:00:00Z"},
+		"p4": {"Phone Case", "xiaomi", []string{"accessory"}, 19, "2024-03-10T00:00:00Z"},
+		"p5": {"Green Watch", "huawei", []string{"watch"}, 199, "2024-04-01T00:00:00Z"},
+	}
+	for id, product := range products {
+		if err := mc.CreateIndexDoc("products", product, id); err != nil {
+			t.Fatal("Create doc", id, "err:", err)
+		}
+	}
+	return mc
+}
+
+// Return the ids of search hits in order.
+func hitIDs(rst *SearchResult[memProduct]) []string {
+	ids := []string{}
+	for _, hit := range rst.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+// Test MemClient queries match the same documents as elasticsearch.
+func TestMemQuery(t *testing.T) {
+	mc := newMemProducts(t)
+	cases := []struct {
+		Case  string
+		Query any
+		IDs   []string
+	}{
+		{"Term keyword", Term("brand", "apple"), []string{"p1", "p3"}},
+		{"Term array", Term("tags", "red"), []string{"p1", "p3"}},
+		{"Term unmatch", Term("brand", "Apple"), []string{}},
+		{"Terms", Terms("brand", "huawei", "xiaomi"), []string{"p4", "p5", "p2"}},
+		{"Match", Match("title", "phone"), []string{"p4", "p2", "p1"}},
+		{"Match any words", Match("title", "red phone"), []string{"p4", "p2", "p1", "p3"}},
+		{"Match case insensitive", Match("title", "LAPTOP"), []string{"p3"}},
+		{"Match phrase", MatchPhrase("title", "phone case"), []string{"p4"}},
+		{"Range number", Range("price").Gte(199).Lt(1000), []string{"p5", "p2", "p1"}},
+		{"Range date", Range("created").Gte("2024-03-01"), []string{"p4", "p5", "p3"}},
+		{"Bool must filter", Bool().Must(Match("title", "phone")).Filter(Term("brand", "xiaomi")).
+			MustNot(Term("tags", "accessory")), []string{"p2"}},
+		{"Bool should", Bool().Should(Term("brand", "huawei"), Term("tags", "laptop")), []string{"p5", "p3"}},
+		{"Bool minimum should", Bool().Should(Term("brand", "apple"), Term("tags", "red"), Term("tags", "phone")).
+			MinimumShould(2), []string{"p1", "p3"}},
+		{"Bool nested", Bool().Filter(Bool().Should(Term("brand", "apple"), Range("price").Lt(100))),
+			[]string{"p4", "p1", "p3"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			sb := NewSearch().Query(c.Query).Sort("price")
+			rst, err := Search[memProduct](context.Background(), mc, "products", sb)
+			if err != nil {
+				t.Fatal("Search, err:", err)
+			} else if ids := hitIDs(rst); !slices.Equal(ids, c.IDs) || rst.Total != len(c.IDs) {
+				t.Fatal("Unmatched hits:", ids, "total:", rst.Total, "want:", c.IDs)
+			}
+		})
+	}
+}
+
+// Test MemClient sort and paging by from size and search after.
+func TestMemPaging(t *testing.T) {
+	mc := newMemProducts(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		Case string
+		Sb   *SearchBuilder
+		IDs  []string
+	}{
+		{"Sort desc", NewSearch().Sort("price", true), []string{"p3", "p1", "p2", "p5", "p4"}},
+		{"Sort by keyword", NewSearch().Sort("brand").Sort("price", true), []string{"p3", "p1", "p5", "p2", "p4"}},
+		{"From size", NewSearch().Sort("price").From(2).Size(2), []string{"p2", "p1"}},
+		{"From over total", NewSearch().Sort("price").From(5).Size(2), []string{}},
+		{"Search after", NewSearch().Sort("price").SearchAfter(199.0).Size(2), []string{"p2", "p1"}},
+	}
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			rst, err := Search[memProduct](ctx, mc, "products", c.Sb)
+			if err != nil {
+				t.Fatal("Search, err:", err)
+			} else if ids := hitIDs(rst); !slices.Equal(ids, c.IDs) || rst.Total != 5 {
+				t.Fatal("Unmatched hits:", ids, "total:", rst.Total, "want:", c.IDs)
+			}
+		})
+	}
+
+	t.Run("Search after pages", func(t *testing.T) {
+		ids, after := []string{}, []any(nil)
+		for page := 0; page < 5; page++ {
+			sb := NewSearch().Sort("brand").Sort("_id").Size(2)
+			if after != nil {
+				sb.SearchAfter(after...)
+			}
+
+			rst, err := Search[memProduct](ctx, mc, "products", sb)
+			if err != nil {
+				t.Fatal("Search page", page, "err:", err)
+			} else if len(rst.Hits) == 0 {
+				break
+			}
+			ids = append(ids, hitIDs(rst)...)
+			after = rst.Hits[len(rst.Hits)-1].Sort
+		}
+
+		if want := []string{"p1", "p3", "p5", "p2", "p4"}; !slices.Equal(ids, want) {
+			t.Fatal("Unmatched paged hits:", ids, "want:", want)
+		}
+	})
+}
+
+// Test MemClient aggregations results as elasticsearch.
+func TestMemAggs(t *testing.T) {
+	mc := newMemProducts(t)
+	type bucket struct {
+		Key      string
+		DocCount int64
+	}
+
+	cases := []struct {
+		Case    string
+		Agg     *Agg
+		Buckets []bucket
+		SumODC  int64
+	}{
+		{"Terms order by count and key", TermsAgg("brand", 0),
+			[]bucket{{"apple", 2}, {"xiaomi", 2}, {"huawei", 1}}, 0},
+		{"Terms size", TermsAgg("brand", 2), []bucket{{"apple", 2}, {"xiaomi", 2}}, 1},
+		{"Terms array field", TermsAgg("tags", 2), []bucket{{"phone", 2}, {"red", 2}}, 4},
+		{"Date histogram month", DateHistogramAgg("created", "month"), []bucket{
+			{"2024-01-01T00:00:00.000Z", 2}, {"2024-02-01T00:00:00.000Z", 0},
+			{"2024-03-01T00:00:00.000Z", 2}, {"2024-04-01T00:00:00.000Z", 1},
+		}, 0},
+		{"Date histogram week", NewAgg("date_histogram", map[string]any{
+			"field": "created", "calendar_interval": "1w", "min_doc_count": 1,
+		}), []bucket{
+			{"2024-01-15T00:00:00.000Z", 2}, {"2024-02-26T00:00:00.000Z", 1},
+			{"2024-03-04T00:00:00.000Z", 1}, {"2024-04-01T00:00:00.000Z", 1},
+		}, 0},
+		{"Date histogram format", NewAgg("date_histogram", map[string]any{
+			"field": "created", "calendar_interval": "quarter", "format": "yyyy-MM-dd",
+		}), []bucket{{"2024-01-01", 4}, {"2024-04-01", 1}}, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			sb := NewSearch().Size(0).Agg("rst", c.Agg)
+			resp, err := mc.DoSearch(context.Background(), "products", sb)
+			if err != nil {
+				t.Fatal("Search, err:", err)
+			}
+
+			agg := resp.Aggregations.Get("rst")
+			if agg == nil || len(agg.Buckets) != len(c.Buckets) || agg.SumODC != c.SumODC {
+				t.Fatal("Unmatched aggregation:", agg, "want:", c.Buckets)
+			}
+			for i, b := range agg.Buckets {
+				if b.KeyStr != c.Buckets[i].Key || b.DocCount != c.Buckets[i].DocCount {
+					t.Fatal("Unmatched bucket", i, "key:", b.KeyStr, "count:", b.DocCount, "want:", c.Buckets[i])
+				}
+			}
+		})
+	}
+
+	t.Run("Date histogram key", func(t *testing.T) {
+		sb := NewSearch().Size(0).Agg("rst", DateHistogramAgg("created", "month"))
+		resp, err := mc.DoSearch(context.Background(), "products", sb)
+		if err != nil {
+			t.Fatal("Search, err:", err)
+		}
+
+		keys := []float64{}
+		for _, b := range resp.Aggregations.Get("rst").Buckets {
+			key, _ := b.Key.(float64)
+			keys = append(keys, key)
+		}
+		if want := []float64{1704067200000, 1706745600000, 1709251200000, 1711929600000}; !slices.Equal(keys, want) {
+			t.Fatal("Unmatched epoch keys:", keys, "want:", want)
+		}
+	})
+
+	t.Run("Terms sub metric", func(t *testing.T) {
+		sb := NewSearch().Size(0).Agg("rst", TermsAgg("brand", 0).Sub("avg_price", MetricAgg("avg", "price")))
+		resp, err := mc.DoSearch(context.Background(), "products", sb)
+		if err != nil {
+			t.Fatal("Search, err:", err)
+		}
+
+		want := map[string]float64{"apple": 1499, "xiaomi": 159, "huawei": 199}
+		for _, b := range resp.Aggregations.Get("rst").Buckets {
+			if avg := b.Get("avg_price"); avg == nil || avg.Value == nil || *avg.Value != want[b.KeyStr] {
+				t.Fatal("Unmatched avg price of", b.KeyStr, "result:", avg)
+			}
+		}
+	})
+}
+
+// Test MemClient bulk indexer apply actions and report item errors.
+func TestMemBulk(t *testing.T) {
+	mc := newMemProducts(t)
+	failed := &atomic.Int64{}
+	bi, err := mc.NewBulkIndexer(&BulkOptions{Workers: 1, OnError: func(item *BulkItem, err error) {
+		failed.Add(1)
+	}})
+	if err != nil {
+		t.Fatal("Create bulk indexer, err:", err)
+	}
+
+	ctx := context.Background()
+	bi.Index("products", "p6", &memProduct{Title: "Black Tablet", Brand: "apple", Price: 599})
+	bi.Update("products", "p1", map[string]any{"price": 899})
+	bi.Delete("products", "p4")
+	bi.Add(ctx, &BulkItem{Action: BulkCreate, Index: "products", DocID: "p2", Doc: &memProduct{Title: "Conflict"}})
+	bi.Delete("products", "p404")
+	if err := bi.Close(ctx); err != nil {
+		t.Fatal("Close bulk indexer, err:", err)
+	}
+
+	if stats := bi.Stats(); stats.Indexed != 3 || stats.Failed != 2 || failed.Load() != 2 {
+		t.Fatal("Unmatched bulk stats:", stats, "failed callbacks:", failed.Load())
+	}
+
+	doc, err := mc.GetIndexDoc("products", "p1")
+	product := &memProduct{}
+	if err != nil || json.Unmarshal(doc, product) != nil || product.Price != 899 || product.Brand != "apple" {
+		t.Fatal("Unmatched updated doc:", string(doc), "err:", err)
+	} else if _, err := mc.GetIndexDoc("products", "p4"); err == nil {
+		t.Fatal("Doc p4 not deleted")
+	} else if doc, err := mc.GetIndexDoc("products", "p2"); err != nil || json.Unmarshal(doc, product) != nil || product.Title != "Blue Phone" {
+		t.Fatal("Doc p2 replaced by create action:", string(doc))
+	}
+
+	rst, err := Search[memProduct](ctx, mc, "products", NewSearch().Query(Term("brand", "apple")).Sort("price"))
+	if ids := hitIDs(rst); err != nil || !slices.Equal(ids, []string{"p6", "p1", "p3"}) {
+		t.Fatal("Unmatched searched bulk docs:", ids, "err:", err)
+	}
+}
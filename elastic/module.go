@@ -18,6 +18,8 @@ type Response struct {
 	Shards       *Shards       `json:"_shards"`
 	Hits         *SearchHits   `json:"hits"`
 	Aggregations *Aggregations `json:"aggregations"`
+	ScrollID     string        `json:"_scroll_id,omitempty"` // scroll id of scroll search
+	PitID        string        `json:"pit_id,omitempty"`     // point in time id of pit search
 }
 
 type Shards struct {
@@ -39,15 +41,39 @@ type Total struct {
 }
 
 type SearchHit struct {
-	Index  string          `json:"_index"`
-	ID     string          `json:"_id"`
-	Score  *float64        `json:"_score,omitempty"`  // computed score
-	Source json.RawMessage `json:"_source,omitempty"` // stored document source
-
+	Index     string              `json:"_index"`
+	ID        string              `json:"_id"`
+	Score     *float64            `json:"_score,omitempty"`    // computed score
+	Source    json.RawMessage     `json:"_source,omitempty"`   // stored document source
+	Highlight map[string][]string `json:"highlight,omitempty"` // highlight fragments of fields
+	Sort      []any               `json:"sort,omitempty"`      // sort values for search_after
 }
 
+// Aggregations results, the TopN filled for compatible with the 'topN'
+// terms aggregation, and the Items contain all aggregations tree.
 type Aggregations struct {
-	TopN *TopN `json:"topN"`
+	TopN  *TopN                 `json:"topN"`
+	Items map[string]*AggResult `json:"-"`
+}
+
+// Aggregation result of metric or bucket aggregation.
+type AggResult struct {
+	Value    *float64              // Metric value, such as avg, sum, cardinality
+	ValueStr string                // Metric value as string, such as date max
+	DocCount int64                 // Doc count of single bucket aggregation, such as filter, nested
+	DocCEUB  int64                 // Doc count error upper bound of terms aggregation
+	SumODC   int64                 // Sum of other doc count of terms aggregation
+	Buckets  []*AggBucket          // Buckets of multiple buckets aggregation
+	Aggs     map[string]*AggResult // Sub aggregations of single bucket aggregation
+	Raw      json.RawMessage       // Raw result for other aggregations, such as stats, top_hits
+}
+
+// Aggregation bucket with sub aggregations.
+type AggBucket struct {
+	Key      any                   // Bucket key as string or number
+	KeyStr   string                // Bucket key as string, such as date histogram key
+	DocCount int64                 // Bucket doc count
+	Aggs     map[string]*AggResult // Sub aggregations of bucket
 }
 
 type TopN struct {
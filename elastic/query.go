@@ -0,0 +1,420 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import "encoding/json"
+
+// Query clause of elasticsearch DSL, it marshal to json object directly.
+//
+//	q := elastic.Match("title", "phone")         // {"match":{"title":"phone"}}
+//	q := elastic.Term("status", 1)               // {"term":{"status":1}}
+//	q := elastic.Range("price").Gte(10).Lt(100)  // {"range":{"price":{"gte":10,"lt":100}}}
+type Query map[string]any
+
+// Bool query builder to combine must, should, filter and must_not clauses.
+//
+//	q := elastic.Bool().
+//		Must(elastic.Match("title", keyword)).
+//		Filter(elastic.Term("status", 1), elastic.Range("price").Lte(100)).
+//		MustNot(elastic.Exists("deleted")).Query()
+type BoolQuery struct {
+	must, should, filter, mustnot []any
+	minshould                     any
+}
+
+// Range query builder of field.
+type RangeQuery struct {
+	field  string
+	params map[string]any
+}
+
+// Search request builder to output search body.
+//
+// # USAGE:
+//
+//	sb := elastic.NewSearch().
+//		Query(elastic.Bool().Must(elastic.Match("title", "phone")).Query()).
+//		Sort("price", true).Sort("_id", false).
+//		Highlight("title").Size(20).
+//		Agg("brands", elastic.TermsAgg("brand", 10).Sub("avg_price", elastic.MetricAgg("avg", "price")))
+//	rst, err := elastic.Search[Product](ctx, elastic.GetEs(), "products", sb)
+type SearchBuilder struct {
+	query       any
+	sorts       []any
+	from, size  int
+	source      any // Source fields, or false to disable
+	highlight   map[string]any
+	searchafter []any
+	aggs        map[string]*Agg
+	trackTotal  any
+}
+
+// Aggregation builder with sub aggregations.
+type Agg struct {
+	kind   string
+	params any
+	subs   map[string]*Agg
+}
+
+/* ------------------------------------------------------------------- */
+/* For Query Clauses                                                   */
+/* ------------------------------------------------------------------- */
+
+// Return match_all query.
+func MatchAll() Query {
+	return Query{"match_all": map[string]any{}}
+}
+
+// Return match query of analyzed text field.
+func Match(field string, text any) Query {
+	return Query{"match": map[string]any{field: text}}
+}
+
+// Return match_phrase query of analyzed text field.
+func MatchPhrase(field string, text any) Query {
+	return Query{"match_phrase": map[string]any{field: text}}
+}
+
+// Return multi_match query of text on multiple fields.
+func MultiMatch(text any, fields ...string) Query {
+	return Query{"multi_match": map[string]any{"query": text, "fields": fields}}
+}
+
+// Return term query of exact value.
+func Term(field string, value any) Query {
+	return Query{"term": map[string]any{field: value}}
+}
+
+// Return terms query of any exact values.
+func Terms[T any](field string, values ...T) Query {
+	return Query{"terms": map[string]any{field: values}}
+}
+
+// Return exists query of field.
+func Exists(field string) Query {
+	return Query{"exists": map[string]any{"field": field}}
+}
+
+// Return ids query of documents ids.
+func IDs(ids ...string) Query {
+	return Query{"ids": map[string]any{"values": ids}}
+}
+
+// Return prefix query of keyword field.
+func Prefix(field, prefix string) Query {
+	return Query{"prefix": map[string]any{field: prefix}}
+}
+
+// Return nested query on the nested objects path.
+//
+//	q := elastic.Nested("skus", elastic.Term("skus.color", "red"))
+func Nested(path string, query any) Query {
+	return Query{"nested": map[string]any{"path": path, "query": query}}
+}
+
+// Return geo_distance query to filter documents within distance of point,
+// the distance formated as '10km', '500m'.
+func GeoDistance(field string, lat, lon float64, distance string) Query {
+	return Query{"geo_distance": map[string]any{
+		"distance": distance, field: map[string]float64{"lat": lat, "lon": lon},
+	}}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Bool And Range Queries                                          */
+/* ------------------------------------------------------------------- */
+
+// Create bool query builder.
+func Bool() *BoolQuery {
+	return &BoolQuery{}
+}
+
+// Append must clauses that contribute to score.
+func (b *BoolQuery) Must(queries ...any) *BoolQuery {
+	b.must = append(b.must, queries...)
+	return b
+}
+
+// Append should clauses.
+func (b *BoolQuery) Should(queries ...any) *BoolQuery {
+	b.should = append(b.should, queries...)
+	return b
+}
+
+// Append filter clauses that not contribute to score.
+func (b *BoolQuery) Filter(queries ...any) *BoolQuery {
+	b.filter = append(b.filter, queries...)
+	return b
+}
+
+// Append must_not clauses.
+func (b *BoolQuery) MustNot(queries ...any) *BoolQuery {
+	b.mustnot = append(b.mustnot, queries...)
+	return b
+}
+
+// Set minimum_should_match as number or percent string like '75%'.
+func (b *BoolQuery) MinimumShould(match any) *BoolQuery {
+	b.minshould = match
+	return b
+}
+
+// Return bool query clause.
+func (b *BoolQuery) Query() Query {
+	params := map[string]any{}
+	for key, clauses := range map[string][]any{
+		"must": b.must, "should": b.should, "filter": b.filter, "must_not": b.mustnot,
+	} {
+		if len(clauses) > 0 {
+			params[key] = clauses
+		}
+	}
+	if b.minshould != nil {
+		params["minimum_should_match"] = b.minshould
+	}
+	return Query{"bool": params}
+}
+
+// Marshal bool query as json object.
+func (b *BoolQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Query())
+}
+
+// Create range query builder of field.
+func Range(field string) *RangeQuery {
+	return &RangeQuery{field: field, params: map[string]any{}}
+}
+
+// Set greater than value.
+func (r *RangeQuery) Gt(value any) *RangeQuery { r.params["gt"] = value; return r }
+
+// Set greater than or equal value.
+func (r *RangeQuery) Gte(value any) *RangeQuery { r.params["gte"] = value; return r }
+
+// Set less than value.
+func (r *RangeQuery) Lt(value any) *RangeQuery { r.params["lt"] = value; return r }
+
+// Set less than or equal value.
+func (r *RangeQuery) Lte(value any) *RangeQuery { r.params["lte"] = value; return r }
+
+// Set date format of range values, such as 'yyyy-MM-dd'.
+func (r *RangeQuery) Format(format string) *RangeQuery { r.params["format"] = format; return r }
+
+// Return range query clause.
+func (r *RangeQuery) Query() Query {
+	return Query{"range": map[string]any{r.field: r.params}}
+}
+
+// Marshal range query as json object.
+func (r *RangeQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Query())
+}
+
+/* ------------------------------------------------------------------- */
+/* For Search Builder                                                  */
+/* ------------------------------------------------------------------- */
+
+// Create search builder, the size default 10 as elasticsearch.
+func NewSearch() *SearchBuilder {
+	return &SearchBuilder{from: -1, size: -1}
+}
+
+// Set search query, it accept Query, *BoolQuery, *RangeQuery or any
+// json marshalable clause.
+func (s *SearchBuilder) Query(query any) *SearchBuilder {
+	s.query = query
+	return s
+}
+
+// Append sort field in ascending or descending order.
+func (s *SearchBuilder) Sort(field string, desc ...bool) *SearchBuilder {
+	order := "asc"
+	if len(desc) > 0 && desc[0] {
+		order = "desc"
+	}
+	s.sorts = append(s.sorts, map[string]any{field: map[string]string{"order": order}})
+	return s
+}
+
+// Append geo distance sort by the point, nearest first.
+func (s *SearchBuilder) SortGeo(field string, lat, lon float64) *SearchBuilder {
+	s.sorts = append(s.sorts, map[string]any{"_geo_distance": map[string]any{
+		field: map[string]float64{"lat": lat, "lon": lon}, "order": "asc", "unit": "m",
+	}})
+	return s
+}
+
+// Set the start offset of hits.
+func (s *SearchBuilder) From(from int) *SearchBuilder {
+	s.from = from
+	return s
+}
+
+// Set the max hits to return.
+func (s *SearchBuilder) Size(size int) *SearchBuilder {
+	s.size = size
+	return s
+}
+
+// Set the source fields to return.
+func (s *SearchBuilder) Source(fields ...string) *SearchBuilder {
+	s.source = nil
+	if len(fields) > 0 {
+		s.source = fields
+	}
+	return s
+}
+
+// Disable the source of hits, only return the ids and sort values.
+func (s *SearchBuilder) NoSource() *SearchBuilder {
+	s.source = false
+	return s
+}
+
+// Set highlight fields with default <em> tags.
+func (s *SearchBuilder) Highlight(fields ...string) *SearchBuilder {
+	hfields := map[string]any{}
+	for _, field := range fields {
+		hfields[field] = map[string]any{}
+	}
+	s.highlight = map[string]any{"fields": hfields}
+	return s
+}
+
+// Set highlight fields with custom tags.
+func (s *SearchBuilder) HighlightTags(pretag, posttag string, fields ...string) *SearchBuilder {
+	s.Highlight(fields...)
+	s.highlight["pre_tags"], s.highlight["post_tags"] = []string{pretag}, []string{posttag}
+	return s
+}
+
+// Set search_after values from the sort values of last hit, the sorts must
+// be set and contain an unique field as tiebreaker.
+func (s *SearchBuilder) SearchAfter(values ...any) *SearchBuilder {
+	s.searchafter = values
+	return s
+}
+
+// Set whether track the accurate total hits, or track up to the given number.
+func (s *SearchBuilder) TrackTotal(track any) *SearchBuilder {
+	s.trackTotal = track
+	return s
+}
+
+// Append named aggregation.
+func (s *SearchBuilder) Agg(name string, agg *Agg) *SearchBuilder {
+	if s.aggs == nil {
+		s.aggs = map[string]*Agg{}
+	}
+	s.aggs[name] = agg
+	return s
+}
+
+// Return the search body as map.
+func (s *SearchBuilder) Body() map[string]any {
+	body := map[string]any{}
+	if s.query != nil {
+		body["query"] = s.query
+	}
+	if len(s.sorts) > 0 {
+		body["sort"] = s.sorts
+	}
+	if s.from >= 0 {
+		body["from"] = s.from
+	}
+	if s.size >= 0 {
+		body["size"] = s.size
+	}
+	if s.source != nil {
+		body["_source"] = s.source
+	}
+	if s.highlight != nil {
+		body["highlight"] = s.highlight
+	}
+	if len(s.searchafter) > 0 {
+		body["search_after"] = s.searchafter
+	}
+	if len(s.aggs) > 0 {
+		body["aggs"] = s.aggs
+	}
+	if s.trackTotal != nil {
+		body["track_total_hits"] = s.trackTotal
+	}
+	return body
+}
+
+// Marshal search body as json object.
+func (s *SearchBuilder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Body())
+}
+
+/* ------------------------------------------------------------------- */
+/* For Aggregation Builders                                            */
+/* ------------------------------------------------------------------- */
+
+// Create aggregation of any kind with params.
+//
+//	agg := elastic.NewAgg("percentiles", map[string]any{"field": "load_time"})
+func NewAgg(kind string, params any) *Agg {
+	return &Agg{kind: kind, params: params}
+}
+
+// Create terms bucket aggregation, the default size 10 used when size is 0.
+func TermsAgg(field string, size int) *Agg {
+	params := map[string]any{"field": field}
+	if size > 0 {
+		params["size"] = size
+	}
+	return NewAgg("terms", params)
+}
+
+// Create date_histogram bucket aggregation with calendar interval as 'day', 'month'.
+func DateHistogramAgg(field, interval string) *Agg {
+	return NewAgg("date_histogram", map[string]any{"field": field, "calendar_interval": interval})
+}
+
+// Create histogram bucket aggregation with number interval.
+func HistogramAgg(field string, interval float64) *Agg {
+	return NewAgg("histogram", map[string]any{"field": field, "interval": interval})
+}
+
+// Create metric aggregation as 'avg', 'sum', 'min', 'max', 'cardinality', 'value_count'.
+func MetricAgg(kind, field string) *Agg {
+	return NewAgg(kind, map[string]any{"field": field})
+}
+
+// Create filter single bucket aggregation.
+func FilterAgg(query any) *Agg {
+	return NewAgg("filter", query)
+}
+
+// Create nested single bucket aggregation on the nested objects path.
+func NestedAgg(path string) *Agg {
+	return NewAgg("nested", map[string]any{"path": path})
+}
+
+// Append named sub aggregation.
+func (a *Agg) Sub(name string, agg *Agg) *Agg {
+	if a.subs == nil {
+		a.subs = map[string]*Agg{}
+	}
+	a.subs[name] = agg
+	return a
+}
+
+// Marshal aggregation as json object.
+func (a *Agg) MarshalJSON() ([]byte, error) {
+	body := map[string]any{a.kind: a.params}
+	if len(a.subs) > 0 {
+		body["aggs"] = a.subs
+	}
+	return json.Marshal(body)
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Typed search hit with decoded document source.
+type Hit[T any] struct {
+	Index     string              // Index name
+	ID        string              // Document id
+	Score     *float64            // Computed score
+	Source    T                   // Decoded document source
+	Highlight map[string][]string // Highlight fragments of fields
+	Sort      []any               // Sort values for search_after
+}
+
+// Typed search result.
+type SearchResult[T any] struct {
+	Took         int           // Search took time in milliseconds
+	Total        int           // Total hits
+	Relation     string        // Total relation as 'eq' or 'gte'
+	Hits         []*Hit[T]     // Typed hits
+	Aggregations *Aggregations // Aggregations results
+}
+
+// Search by builder and return raw response.
+func (e *ESClient) DoSearch(ctx context.Context, index string, sb *SearchBuilder) (*Response, error) {
+	if e.Conn == nil {
+		return nil, invar.ErrInvalidClient
+	}
+
+	body, err := json.Marshal(sb)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.Conn.Search(
+		e.Conn.Search.WithContext(ctx),
+		e.Conn.Search.WithIndex(index),
+		e.Conn.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		esclog.E("Search index, err:", err)
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	return readResponse(res)
+}
+
+// Search by builder and decode hits source as T type.
+//
+//	sb := elastic.NewSearch().Query(elastic.Match("title", "phone")).Size(20)
+//	rst, err := elastic.Search[Product](ctx, elastic.GetEs(), "products", sb)
+//	for _, hit := range rst.Hits {
+//		logger.I("Product:", hit.Source.Title, hit.Highlight["title"])
+//	}
+func Search[T any](ctx context.Context, c Client, index string, sb *SearchBuilder) (*SearchResult[T], error) {
+	resp, err := c.DoSearch(ctx, index, sb)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeHits[T](resp)
+}
+
+// Decode the search response hits source as T type.
+func DecodeHits[T any](resp *Response) (*SearchResult[T], error) {
+	rst := &SearchResult[T]{Took: resp.Took, Aggregations: resp.Aggregations}
+	if resp.Hits == nil {
+		return rst, nil
+	}
+
+	if total := resp.Hits.Total; total != nil {
+		rst.Total, rst.Relation = total.Values, total.Relation
+	}
+
+	for _, sh := range resp.Hits.Hits {
+		hit := &Hit[T]{Index: sh.Index, ID: sh.ID, Score: sh.Score, Highlight: sh.Highlight, Sort: sh.Sort}
+		if len(sh.Source) > 0 {
+			if err := json.Unmarshal(sh.Source, &hit.Source); err != nil {
+				return nil, err
+			}
+		}
+		rst.Hits = append(rst.Hits, hit)
+	}
+	return rst, nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Aggregations Decoder                                            */
+/* ------------------------------------------------------------------- */
+
+// Decode all aggregations as tree, and fill the 'topN' terms aggregation.
+func (a *Aggregations) UnmarshalJSON(data []byte) error {
+	raws := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+
+	a.Items = parseAggs(raws)
+	if topn, ok := a.Items["topN"]; ok {
+		a.TopN = &TopN{DocCEUB: int(topn.DocCEUB), SumODC: int(topn.SumODC)}
+		for _, bucket := range topn.Buckets {
+			a.TopN.Buckets = append(a.TopN.Buckets, &Buckets{
+				Key: bucket.KeyStr, DocCount: strconv.FormatInt(bucket.DocCount, 10),
+			})
+		}
+	}
+	return nil
+}
+
+// Return the named aggregation result, or nil when not found.
+func (a *Aggregations) Get(name string) *AggResult {
+	if a == nil {
+		return nil
+	}
+	return a.Items[name]
+}
+
+// Return the named sub aggregation result of bucket, or nil when not found.
+func (b *AggBucket) Get(name string) *AggResult {
+	return b.Aggs[name]
+}
+
+// Parse aggregations results from raw datas.
+func parseAggs(raws map[string]json.RawMessage) map[string]*AggResult {
+	aggs := map[string]*AggResult{}
+	for name, raw := range raws {
+		if agg := parseAgg(raw); agg != nil {
+			aggs[name] = agg
+		}
+	}
+	return aggs
+}
+
+// Parse aggregation result, it return nil when raw data not json object.
+func parseAgg(raw json.RawMessage) *AggResult {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	agg, subs := &AggResult{Raw: raw}, map[string]json.RawMessage{}
+	for key, value := range fields {
+		switch key {
+		case "value":
+			json.Unmarshal(value, &agg.Value)
+		case "value_as_string":
+			json.Unmarshal(value, &agg.ValueStr)
+		case "doc_count":
+			json.Unmarshal(value, &agg.DocCount)
+		case "doc_count_error_upper_bound":
+			json.Unmarshal(value, &agg.DocCEUB)
+		case "sum_other_doc_count":
+			json.Unmarshal(value, &agg.SumODC)
+		case "buckets":
+			agg.Buckets = parseBuckets(value)
+		case "meta":
+		default:
+			if len(value) > 0 && value[0] == '{' {
+				subs[key] = value
+			}
+		}
+	}
+
+	if len(subs) > 0 {
+		agg.Aggs = parseAggs(subs)
+	}
+	return agg
+}
+
+// Parse buckets as array, or keyed object of range and filters aggregations.
+func parseBuckets(raw json.RawMessage) []*AggBucket {
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		keys, values, err := orderedFields(raw)
+		if err != nil {
+			return nil
+		}
+
+		buckets := []*AggBucket{}
+		for i, key := range keys {
+			if bucket := parseBucket(values[i]); bucket != nil {
+				bucket.Key, bucket.KeyStr = key, key
+				buckets = append(buckets, bucket)
+			}
+		}
+		return buckets
+	}
+
+	buckets := []*AggBucket{}
+	for _, item := range items {
+		if bucket := parseBucket(item); bucket != nil {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets
+}
+
+// Parse bucket key, doc count and sub aggregations.
+func parseBucket(raw json.RawMessage) *AggBucket {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	bucket, subs := &AggBucket{}, map[string]json.RawMessage{}
+	for key, value := range fields {
+		switch key {
+		case "key":
+			json.Unmarshal(value, &bucket.Key)
+			if bucket.KeyStr == "" {
+				bucket.KeyStr = keyString(bucket.Key)
+			}
+		case "key_as_string":
+			json.Unmarshal(value, &bucket.KeyStr)
+		case "doc_count":
+			json.Unmarshal(value, &bucket.DocCount)
+		default:
+			if len(value) > 0 && value[0] == '{' {
+				subs[key] = value
+			}
+		}
+	}
+
+	if len(subs) > 0 {
+		bucket.Aggs = parseAggs(subs)
+	}
+	return bucket
+}
+
+// Decode the fields of json object in order, it keep the keyed buckets
+// order same as elasticsearch responsed.
+func orderedFields(raw json.RawMessage) ([]string, []json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, invar.ErrInvalidData
+	}
+
+	keys, values := []string{}, []json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := json.RawMessage{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys, values = append(keys, fmt.Sprint(tok)), append(values, value)
+	}
+	return keys, values, nil
+}
+
+// Format bucket key as string, the float64 number format without exponent.
+func keyString(key any) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	}
+	return fmt.Sprint(key)
+}
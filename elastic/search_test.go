@@ -0,0 +1,116 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/elastic, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Aggregations response of elasticsearch.
+const aggsResponse = `{
+	"topN": {
+		"doc_count_error_upper_bound": 0, "sum_other_doc_count": 3,
+		"buckets": [
+			{"key": "apple", "doc_count": 5, "avg_price": {"value": 1499.5}},
+			{"key": "xiaomi", "doc_count": 2, "avg_price": {"value": 159}}
+		]
+	},
+	"prices": {
+		"buckets": {
+			"cheap": {"to": 100, "doc_count": 4},
+			"middle": {"from": 100, "to": 1000, "doc_count": 6},
+			"expensive": {"from": 1000, "doc_count": 1},
+			"another": {"from": 5000, "doc_count": 0}
+		}
+	},
+	"months": {
+		"buckets": [
+			{"key_as_string": "2024-01-01", "key": 1704067200000, "doc_count": 2,
+				"brands": {"doc_count_error_upper_bound": 0, "sum_other_doc_count": 0,
+					"buckets": [{"key": "apple", "doc_count": 2, "max_price": {"value": 999, "value_as_string": "999.0"}}]}}
+		]
+	},
+	"discount": {"doc_count": 7, "avg_price": {"value": 88.5}}
+}`
+
+// Test Aggregations.UnmarshalJSON decode nested aggregations and keyed buckets in order.
+func TestAggregationsUnmarshal(t *testing.T) {
+	for i := 0; i < 10; i++ { // keyed buckets order must be stable.
+		aggs := &Aggregations{}
+		if err := json.Unmarshal([]byte(aggsResponse), aggs); err != nil {
+			t.Fatal("Unmarshal aggregations, err:", err)
+		}
+
+		prices, keys := aggs.Get("prices"), []string{}
+		for _, bucket := range prices.Buckets {
+			keys = append(keys, bucket.KeyStr)
+		}
+		if want := []string{"cheap", "middle", "expensive", "another"}; !slices.Equal(keys, want) {
+			t.Fatal("Unmatched keyed buckets order:", keys)
+		} else if prices.Buckets[1].DocCount != 6 {
+			t.Fatal("Unmatched keyed bucket doc count:", prices.Buckets[1].DocCount)
+		}
+	}
+
+	aggs := &Aggregations{}
+	if err := json.Unmarshal([]byte(aggsResponse), aggs); err != nil {
+		t.Fatal("Unmarshal aggregations, err:", err)
+	}
+
+	t.Run("TopN fill", func(t *testing.T) {
+		topn := aggs.TopN
+		if topn == nil || topn.SumODC != 3 || len(topn.Buckets) != 2 {
+			t.Fatal("Unmatched topN:", topn)
+		} else if topn.Buckets[0].Key != "apple" || topn.Buckets[0].DocCount != "5" {
+			t.Fatal("Unmatched topN bucket:", topn.Buckets[0])
+		} else if avg := aggs.Get("topN").Buckets[0].Get("avg_price"); avg == nil || *avg.Value != 1499.5 {
+			t.Fatal("Unmatched topN sub aggregation:", avg)
+		}
+	})
+
+	t.Run("Nested sub aggregations", func(t *testing.T) {
+		months := aggs.Get("months")
+		if months == nil || len(months.Buckets) != 1 {
+			t.Fatal("Unmatched months aggregation:", months)
+		}
+
+		month := months.Buckets[0]
+		if month.KeyStr != "2024-01-01" || month.Key != float64(1704067200000) || month.DocCount != 2 {
+			t.Fatal("Unmatched month bucket:", month)
+		}
+
+		brands := month.Get("brands")
+		if brands == nil || len(brands.Buckets) != 1 || brands.Buckets[0].KeyStr != "apple" {
+			t.Fatal("Unmatched sub terms aggregation:", brands)
+		} else if max := brands.Buckets[0].Get("max_price"); max == nil || *max.Value != 999 || max.ValueStr != "999.0" {
+			t.Fatal("Unmatched sub metric aggregation:", max)
+		}
+	})
+
+	t.Run("Single bucket", func(t *testing.T) {
+		discount := aggs.Get("discount")
+		if discount == nil || discount.DocCount != 7 {
+			t.Fatal("Unmatched filter aggregation:", discount)
+		} else if avg := discount.Aggs["avg_price"]; avg == nil || *avg.Value != 88.5 {
+			t.Fatal("Unmatched single bucket sub aggregation:", avg)
+		} else if aggs.Get("unexist") != nil {
+			t.Fatal("Unexist aggregation should be nil")
+		}
+	})
+}
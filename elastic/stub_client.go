@@ -11,6 +11,10 @@
 package elastic
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
 	es "github.com/elastic/go-elasticsearch/v8"
 	"github.com/wengoldx/xcore/logger"
 )
@@ -20,9 +24,49 @@ type ESClient struct {
 	Conn *es.Client
 }
 
+// Elasticsearch client interface implemented by ESClient and MemClient, the
+// services depend on it can be tested offline by MemClient.
+//
+//	elastic.UseClient(elastic.NewMemClient()) // in unit tests.
+//	c := elastic.GetClient()
+//	c.CreateIndexDoc("products", product, product.ID)
+//
+// # WARNING:
+//   - The versioned index apis, such as SetupVersionIndexs(), MigrateIndex()
+//     and Reindex(), only implemented by ESClient, MemClient not support
+//     aliases and reindex tasks.
+type Client interface {
+	SetupIndexs(indexs map[string]string) error
+	CreateIndexMapping(index, mapping string) error
+	UpdateIndexMapping(index []string, mapping string) error
+	IsExistIndex(index []string) (bool, error)
+	CreateIndexDoc(index string, doc any, docid ...string) error
+	UpdateIndexDoc(index, docid, doc string) error
+	GetIndexDoc(index, docid string) (json.RawMessage, error)
+	DeleteIndexDoc(index, docid string) error
+	SearchIndex(index, query string, page int, limit ...int) (*Response, error)
+	DoSearch(ctx context.Context, index string, sb *SearchBuilder) (*Response, error)
+
+	// Point in time and scroll apis to iterate deeply, see Iterate().
+	OpenPIT(ctx context.Context, index string, keepalive time.Duration) (string, error)
+	ClosePIT(ctx context.Context, pitid string) error
+	SearchPIT(ctx context.Context, body map[string]any) (*Response, error)
+	OpenScroll(ctx context.Context, index string, body map[string]any, keepalive time.Duration) (*Response, error)
+	Scroll(ctx context.Context, scrollid string, keepalive time.Duration) (*Response, error)
+	ClearScroll(ctx context.Context, scrollid string) error
+
+	// Bulk indexer to batch index, update, delete actions, see BulkIndexer.
+	NewBulkIndexer(opts *BulkOptions) (*BulkIndexer, error)
+}
+
+var _ Client = (*ESClient)(nil)
+
 // Elastic client singleton, setup when DID_ES_AGENTS config received or changed.
 var esc *ESClient
 
+// Elastic client replaced by UseClient(), such as MemClient for tests.
+var escli Client
+
 // Object logger with [ESC] mark for elastic module
 var esclog = logger.CatLogger("ESC")
 
@@ -53,3 +97,16 @@ func GetEs() *ESClient {
 	}
 	return esc
 }
+
+// Replace the client returned by GetClient(), set nil to restore.
+func UseClient(c Client) {
+	escli = c
+}
+
+// Return the client set by UseClient(), or the elastic singleton.
+func GetClient() Client {
+	if escli != nil {
+		return escli
+	}
+	return GetEs()
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Options of versioned index migration.
+type MigrateOptions struct {
+	KeepVersions int                                      // Old versions to keep after swap alias, 0 to keep all
+	PollInterval time.Duration                            // Reindex task polling interval, default 2s
+	OnProgress   func(alias string, created, total int64) // Reindex progress callback, optional
+}
+
+// Reindex task status.
+type reindexTask struct {
+	Completed bool `json:"completed"`
+	Task      struct {
+		Status struct {
+			Total   int64 `json:"total"`
+			Created int64 `json:"created"`
+			Updated int64 `json:"updated"`
+		} `json:"status"`
+	} `json:"task"`
+	Error    *Reason `json:"error"`
+	Response struct {
+		Failures []json.RawMessage `json:"failures"`
+	} `json:"response"`
+}
+
+// Versioned index name format as 'alias_vN'.
+var versionRegexp = regexp.MustCompile(`^(.+)_v(\d+)$`)
+
+// Setup versioned indexs by the same mappings of SetupIndexs(), the map key
+// used as alias name point to the real index 'alias_vN'.
+//
+//   - Create 'alias_v1' and alias when alias unexist.
+//   - Reindex the exist concrete index (created by SetupIndexs) into 'alias_v1',
+//     then delete it and add alias in one atomic aliases action.
+//   - Migrate to next version when the mapping checksum changed.
+//   - Do nothing when the mapping unchanged.
+//
+// # USAGE:
+//
+//	indexs := map[string]string{"products": productMapping}
+//	err := elastic.GetEs().SetupVersionIndexs(ctx, indexs, &elastic.MigrateOptions{KeepVersions: 1})
+//
+// # WARNING:
+//   - The documents written into old index during reindex will not copy into
+//     new index, please pause writing or resync them after migrated.
+func (e *ESClient) SetupVersionIndexs(ctx context.Context, indexs map[string]string, opts ...*MigrateOptions) error {
+	for alias, mapping := range indexs {
+		index, version, err := e.AliasIndex(alias)
+		if err != nil {
+			return err
+		}
+
+		checksum, err := MappingChecksum(mapping)
+		if err != nil {
+			return err
+		}
+
+		if index == "" {
+			if exist, err := e.IsExistIndex([]string{alias}); err != nil {
+				return err
+			} else if exist {
+				if err := e.adoptIndex(ctx, alias, mapping, checksum, opts...); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := e.createVersion(alias, 1, mapping, checksum); err != nil {
+				return err
+			} else if err := e.SwapAlias(alias, "", VersionIndex(alias, 1)); err != nil {
+				return err
+			}
+			esclog.I("Created versioned index", VersionIndex(alias, 1))
+			continue
+		}
+
+		if current, err := e.indexChecksum(index); err != nil {
+			return err // not migrate by transient error.
+		} else if current == checksum {
+			continue
+		}
+
+		esclog.I("Mapping of", alias, "changed, migrate from version", version)
+		if _, err := e.MigrateIndex(ctx, alias, mapping, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate alias to next version index with the mapping, it reindex all
+// documents from current index, then swap alias atomically and return the
+// new index name.
+func (e *ESClient) MigrateIndex(ctx context.Context, alias, mapping string, opts ...*MigrateOptions) (string, error) {
+	opt := &MigrateOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+
+	current, version, err := e.AliasIndex(alias)
+	if err != nil {
+		return "", err
+	} else if current == "" {
+		return "", invar.ErrNotFound
+	}
+
+	checksum, err := MappingChecksum(mapping)
+	if err != nil {
+		return "", err
+	}
+
+	next, err := e.createVersion(alias, version+1, mapping, checksum)
+	if err != nil {
+		return "", err
+	}
+
+	progress := func(created, total int64) {
+		if opt.OnProgress != nil {
+			opt.OnProgress(alias, created, total)
+		}
+	}
+	if err := e.Reindex(ctx, current, next, opt.PollInterval, progress); err != nil {
+		e.DeleteIndexs(next) // drop the incomplete index
+		return "", err
+	}
+
+	if err := e.SwapAlias(alias, current, next); err != nil {
+		return "", err
+	}
+	esclog.I("Swapped alias", alias, "from", current, "to", next)
+
+	if opt.KeepVersions > 0 {
+		e.pruneVersions(alias, version+1, opt.KeepVersions)
+	}
+	return next, nil
+}
+
+// Return the index and version pointed by alias, it return empty index when
+// alias unexist, and version 0 when index name not versioned.
+func (e *ESClient) AliasIndex(alias string) (string, int, error) {
+	if e.Conn == nil || e.Conn.Indices == nil {
+		return "", 0, invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.Indices.GetAlias(e.Conn.Indices.GetAlias.WithName(alias))
+	if err != nil {
+		return "", 0, err
+	} else if res.StatusCode == invar.E404Exception {
+		res.Body.Close()
+		return "", 0, nil
+	}
+
+	indexs := map[string]any{}
+	if err := decodeResp(res, &indexs); err != nil {
+		return "", 0, err
+	}
+
+	// pick the write index when alias point to multiple indexs.
+	latest, version := "", -1
+	for index := range indexs {
+		if v := indexVersion(alias, index); v > version {
+			latest, version = index, v
+		}
+	}
+	return latest, max(version, 0), nil
+}
+
+// Reindex all documents from source index to dest index by server side
+// task, and polling the task progress until completed.
+func (e *ESClient) Reindex(ctx context.Context, src, dst string, interval time.Duration, progress ...func(created, total int64)) error {
+	if e.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	body := fmt.Sprintf(`{"source":{"index":%q},"dest":{"index":%q}}`, src, dst)
+	res, err := e.Conn.Reindex(bytes.NewReader([]byte(body)),
+		e.Conn.Reindex.WithContext(ctx), e.Conn.Reindex.WithWaitForCompletion(false))
+	if err != nil {
+		return err
+	}
+
+	task := struct {
+		Task string `json:"task"`
+	}{}
+	if err := decodeResp(res, &task); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.Conn.Tasks.Cancel(e.Conn.Tasks.Cancel.WithTaskID(task.Task))
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		res, err := e.Conn.Tasks.Get(task.Task, e.Conn.Tasks.Get.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		status := &reindexTask{}
+		if err := decodeResp(res, status); err != nil {
+			return err
+		}
+
+		st := status.Task.Status
+		if len(progress) > 0 && progress[0] != nil {
+			progress[0](st.Created+st.Updated, st.Total)
+		}
+
+		if status.Completed {
+			if status.Error != nil {
+				return fmt.Errorf("reindex %s failed: %s", src, status.Error.Reason)
+			} else if len(status.Response.Failures) > 0 {
+				return fmt.Errorf("reindex %s failed: %s", src, string(status.Response.Failures[0]))
+			}
+			return nil
+		}
+	}
+}
+
+// Swap alias from old index to new index atomically, set from as empty
+// string to only add alias, the alias set as write index of new index.
+func (e *ESClient) SwapAlias(alias, from, to string) error {
+	if e.Conn == nil || e.Conn.Indices == nil {
+		return invar.ErrInvalidClient
+	}
+
+	actions := []any{}
+	if from != "" {
+		actions = append(actions, map[string]any{"remove": map[string]any{"index": from, "alias": alias}})
+	}
+	actions = append(actions, map[string]any{"add": map[string]any{"index": to, "alias": alias, "is_write_index": true}})
+
+	body, _ := json.Marshal(map[string]any{"actions": actions})
+	res, err := e.Conn.Indices.UpdateAliases(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return respError(res)
+}
+
+// Delete the given indexs.
+func (e *ESClient) DeleteIndexs(indexs ...string) error {
+	if e.Conn == nil || e.Conn.Indices == nil {
+		return invar.ErrInvalidClient
+	}
+
+	res, err := e.Conn.Indices.Delete(indexs)
+	if err != nil {
+		return err
+	}
+	return respError(res)
+}
+
+// Return the version index name of alias, such as 'products_v2'.
+func VersionIndex(alias string, version int) string {
+	return alias + "_v" + strconv.Itoa(version)
+}
+
+// Return the checksum of mapping json, it ignore the spaces and fields order.
+func MappingChecksum(mapping string) (string, error) {
+	var value any
+	if err := json.Unmarshal([]byte(mapping), &value); err != nil {
+		return "", invar.ErrInvalidData
+	}
+
+	normalized, _ := json.Marshal(value) // map keys sorted by json marshal.
+	hash := sha256.Sum256(normalized)
+	return hex.EncodeToString(hash[:8]), nil
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Create version index with mapping, the checksum saved into mapping meta.
+func (e *ESClient) createVersion(alias string, version int, mapping, checksum string) (string, error) {
+	body := map[string]any{}
+	if err := json.Unmarshal([]byte(mapping), &body); err != nil {
+		return "", invar.ErrInvalidData
+	}
+
+	mappings, _ := body["mappings"].(map[string]any)
+	if mappings == nil {
+		mappings = map[string]any{}
+		body["mappings"] = mappings
+	}
+
+	meta, _ := mappings["_meta"].(map[string]any)
+	if meta == nil {
+		meta = map[string]any{}
+		mappings["_meta"] = meta
+	}
+	meta["checksum"], meta["version"] = checksum, version
+
+	buf, _ := json.Marshal(body)
+	index := VersionIndex(alias, version)
+	return index, e.CreateIndexMapping(index, string(buf))
+}
+
+// Reindex the concrete index named as alias into 'alias_v1', then delete the
+// concrete index and add alias to 'alias_v1' atomically, so the apps setup
+// by SetupIndexs() can upgrade to versioned indexs.
+func (e *ESClient) adoptIndex(ctx context.Context, alias, mapping, checksum string, opts ...*MigrateOptions) error {
+	opt := &MigrateOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+
+	first, err := e.createVersion(alias, 1, mapping, checksum)
+	if err != nil {
+		return fmt.Errorf("create %s to adopt concrete index %s, err: %v", first, alias, err)
+	}
+
+	esclog.I("Adopt concrete index", alias, "by reindex into", first)
+	progress := func(created, total int64) {
+		if opt.OnProgress != nil {
+			opt.OnProgress(alias, created, total)
+		}
+	}
+	if err := e.Reindex(ctx, alias, first, opt.PollInterval, progress); err != nil {
+		e.DeleteIndexs(first) // drop the incomplete index
+		return fmt.Errorf("reindex concrete index %s into %s, err: %v", alias, first, err)
+	}
+
+	actions := []any{
+		map[string]any{"add": map[string]any{"index": first, "alias": alias, "is_write_index": true}},
+		map[string]any{"remove_index": map[string]any{"index": alias}},
+	}
+	body, _ := json.Marshal(map[string]any{"actions": actions})
+	res, err := e.Conn.Indices.UpdateAliases(bytes.NewReader(body))
+	if err == nil {
+		err = respError(res)
+	}
+	if err != nil {
+		return fmt.Errorf("replace concrete index %s by alias to %s, please delete the index "+
+			"and add alias manually, err: %v", alias, first, err)
+	}
+
+	esclog.I("Replaced concrete index", alias, "by alias to", first)
+	return nil
+}
+
+// Return the mapping checksum saved in index mapping meta.
+func (e *ESClient) indexChecksum(index string) (string, error) {
+	res, err := e.Conn.Indices.GetMapping(e.Conn.Indices.GetMapping.WithIndex(index))
+	if err != nil {
+		return "", err
+	}
+
+	mappings := map[string]struct {
+		Mappings struct {
+			Meta map[string]any `json:"_meta"`
+		} `json:"mappings"`
+	}{}
+	if err := decodeResp(res, &mappings); err != nil {
+		return "", err
+	}
+
+	checksum, _ := mappings[index].Mappings.Meta["checksum"].(string)
+	return checksum, nil
+}
+
+// Delete the old versions of alias and keep the latest versions.
+func (e *ESClient) pruneVersions(alias string, current, keep int) {
+	res, err := e.Conn.Indices.Get([]string{alias + "_v*"})
+	if err != nil {
+		return
+	}
+
+	indexs := map[string]any{}
+	if err := decodeResp(res, &indexs); err != nil {
+		return
+	}
+
+	versions := []int{}
+	for index := range indexs {
+		if v := indexVersion(alias, index); v > 0 && v < current {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	for i := keep; i < len(versions); i++ {
+		index := VersionIndex(alias, versions[i])
+		if err := e.DeleteIndexs(index); err != nil {
+			esclog.E("Delete old index", index, "err:", err)
+			continue
+		}
+		esclog.I("Deleted old index", index)
+	}
+}
+
+// Return the version number of alias index, or 0 when not versioned.
+func indexVersion(alias, index string) int {
+	if matchs := versionRegexp.FindStringSubmatch(index); len(matchs) == 3 && matchs[1] == alias {
+		v, _ := strconv.Atoi(matchs[2])
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,373 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Message context of routed topic handler.
+type Context struct {
+	Stub    *MqttStub         // Mqtt stub of router
+	Message mq.Message        // Received raw message
+	Topic   string            // Received message topic
+	Pattern string            // Route pattern matched the topic
+	Params  map[string]string // Named segment values, such as 'id' of 'devices/{id}/telemetry'
+	Wilds   []string          // Wildcard '+' and '#' segment values in order
+	codec   Codec
+}
+
+// Topic handler of router.
+type HandlerFunc func(c *Context) error
+
+// Middleware to wrap topic handler, such as logging and metrics.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Options of route.
+type RouteOptions struct {
+	Qos         byte  // Subscribe qos, default use stub qos, must same as the first route of filter
+	Concurrency int   // Max handlers run in parallel, default 0 to run one by one in receive order
+	Codec       Codec // Payload codec, default use router codec
+}
+
+// Topic router to dispatch messages to handlers registered on patterns,
+// the pattern support mqtt wildcards '+' and '#', and named segments as
+// '{name}' which match single level same as '+'.
+//
+// The handler panic will be recovered and logged, and the routes with same
+// subscribe filter, such as 'devices/{id}/state' and 'devices/+/state',
+// share one subscription.
+//
+// # USAGE:
+//
+//	r := mqtt.NewRouter(mqtt.Singleton())
+//	r.Use(mqtt.Logging())
+//	mqtt.On(r, "devices/{id}/telemetry", func(c *mqtt.Context, t *Telemetry) error {
+//		return saveTelemetry(c.Param("id"), t)
+//	}, &mqtt.RouteOptions{Concurrency: 8})
+//
+// # WARNING:
+//   - Call routes registing after mqtt client connected.
+//   - The handler with concurrency will block the messages receiving when
+//     reached the concurrency limit.
+//   - The routes of same filter share the qos of first route, the other
+//     qos rejected as invar.ErrInvalidParams.
+type Router struct {
+	stub        *MqttStub
+	codec       Codec
+	lock        sync.RWMutex
+	filters     map[string]*routeFilter
+	middlewares []Middleware
+}
+
+// Routes of subscribe filter, the filter subscribed once by first route.
+type routeFilter struct {
+	routes []*route
+	qos    byte
+	ready  chan struct{} // Closed after subscribe finished
+	err    error         // Subscribe error, set before ready closed
+}
+
+// Route of topic pattern.
+type route struct {
+	pattern  string
+	segments []string
+	handler  HandlerFunc
+	codec    Codec
+	sem      chan struct{}
+}
+
+// Create topic router on mqtt stub, the payload codec default as json.
+func NewRouter(stub *MqttStub, codec ...Codec) *Router {
+	if stub == nil {
+		stub = Singleton()
+	}
+
+	r := &Router{stub: stub, codec: JSONCodec, filters: make(map[string]*routeFilter)}
+	if len(codec) > 0 && codec[0] != nil {
+		r.codec = codec[0]
+	}
+	return r
+}
+
+// Append middlewares to wrap all handlers, the first one is the outermost.
+func (r *Router) Use(middlewares ...Middleware) *Router {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+	return r
+}
+
+// Register handler on topic pattern and subscribe the pattern filter.
+func (r *Router) Handle(pattern string, handler HandlerFunc, opts ...*RouteOptions) error {
+	filter, segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	} else if handler == nil {
+		return invar.ErrInvalidParams
+	}
+
+	opt := &RouteOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	}
+
+	rt := &route{pattern: pattern, segments: segments, handler: handler, codec: r.codec}
+	if opt.Codec != nil {
+		rt.codec = opt.Codec
+	}
+	if opt.Concurrency > 0 {
+		rt.sem = make(chan struct{}, opt.Concurrency)
+	}
+
+	r.lock.Lock()
+	rf, exist := r.filters[filter]
+	if !exist {
+		rf = &routeFilter{qos: opt.Qos, ready: make(chan struct{})}
+		r.filters[filter] = rf
+	} else if opt.Qos != rf.qos {
+		r.lock.Unlock()
+		mqxlog.E("Route:", pattern, "qos:", opt.Qos, "unmatch filter qos:", rf.qos)
+		return invar.ErrInvalidParams
+	}
+	rf.routes = append(rf.routes, rt)
+	r.lock.Unlock()
+
+	// Subscribe out of lock to not block dispatching, the concurrent
+	// routes of same filter wait the first one subscribed.
+	if !exist {
+		var qos []byte
+		if opt.Qos > 0 {
+			qos = []byte{opt.Qos}
+		}
+		rf.err = r.stub.Subscribe(filter, r.dispatcher(filter), qos...)
+		close(rf.ready)
+	} else {
+		<-rf.ready
+	}
+
+	if rf.err != nil {
+		r.removeRoute(filter, rf, rt)
+		return rf.err
+	}
+	return nil
+}
+
+// Register typed handler on topic pattern, the payload decoded by route
+// codec, and empty payload passed as zero value.
+func On[T any](r *Router, pattern string, handler func(c *Context, payload *T) error, opts ...*RouteOptions) error {
+	return r.Handle(pattern, func(c *Context) error {
+		payload := new(T)
+		if err := c.Bind(payload); err != nil {
+			return err
+		}
+		return handler(c, payload)
+	}, opts...)
+}
+
+// Encode value by router codec and publish.
+func (r *Router) Publish(topic string, v any, Qos ...byte) error {
+	payload, err := r.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.stub.Publish(topic, payload, Qos...)
+}
+
+// Return the named segment value.
+func (c *Context) Param(name string) string {
+	return c.Params[name]
+}
+
+// Return the raw payload of message.
+func (c *Context) Payload() []byte {
+	return c.Message.Payload()
+}
+
+// Decode payload into v by route codec, it do nothing for empty payload.
+func (c *Context) Bind(v any) error {
+	if payload := c.Payload(); len(payload) > 0 {
+		return c.codec.Unmarshal(payload, v)
+	}
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Router Middlewares                                              */
+/* ------------------------------------------------------------------- */
+
+// Logging middleware to output handled topic, duration and error.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				mqxlog.E("Handle topic:", c.Topic, "duration:", time.Since(start), "err:", err)
+			} else {
+				mqxlog.I("Handled topic:", c.Topic, "duration:", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Metrics middleware to observe handled pattern, duration and error, such
+// as report to prometheus.
+func Metrics(observe func(pattern string, duration time.Duration, err error)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+			observe(c.Pattern, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Return the message handler to dispatch messages of subscribe filter.
+func (r *Router) dispatcher(filter string) mq.MessageHandler {
+	return func(client mq.Client, msg mq.Message) {
+		r.lock.RLock()
+		routes, middlewares := []*route(nil), r.middlewares
+		if rf, ok := r.filters[filter]; ok {
+			routes = rf.routes
+		}
+		r.lock.RUnlock()
+
+		levels := strings.Split(msg.Topic(), "/")
+		for _, rt := range routes {
+			c := &Context{Stub: r.stub, Message: msg, Topic: msg.Topic(), Pattern: rt.pattern, codec: rt.codec}
+			c.Params, c.Wilds = matchLevels(rt.segments, levels)
+
+			handler := recovered(rt.handler)
+			for i := len(middlewares) - 1; i >= 0; i-- {
+				handler = middlewares[i](handler)
+			}
+			rt.serve(c, handler)
+		}
+	}
+}
+
+// Remove the route of failed subscribe filter, and remove the filter when
+// it have no routes, so the next route can subscribe it again.
+func (r *Router) removeRoute(filter string, rf *routeFilter, rt *route) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	rf.routes = slices.DeleteFunc(rf.routes, func(item *route) bool { return item == rt })
+	if len(rf.routes) == 0 && r.filters[filter] == rf {
+		delete(r.filters, filter)
+	}
+}
+
+// Run handler with panic recovery, it run in goroutine when route limit
+// the concurrency.
+func (rt *route) serve(c *Context, handler HandlerFunc) {
+	run := func() {
+		defer func() {
+			if p := recover(); p != nil {
+				mqxlog.E("Handle topic:", c.Topic, "panic:", p)
+			}
+		}()
+
+		if err := handler(c); err != nil {
+			mqxlog.E("Handle topic:", c.Topic, "err:", err)
+		}
+	}
+
+	if rt.sem == nil {
+		run()
+		return
+	}
+
+	rt.sem <- struct{}{}
+	go func() {
+		defer func() { <-rt.sem }()
+		run()
+	}()
+}
+
+// Return handler to recover panic as error, so middlewares can observe it.
+func recovered(handler HandlerFunc) HandlerFunc {
+	return func(c *Context) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return handler(c)
+	}
+}
+
+// Parse pattern to subscribe filter and segments, the named segments
+// replaced as '+' wildcard in filter, and the shared subscription prefix
+// as '$share/group/' excluded from segments.
+func parsePattern(pattern string) (string, []string, error) {
+	if pattern == "" {
+		return "", nil, invar.ErrInvalidParams
+	}
+
+	segments := strings.Split(pattern, "/")
+	levels := make([]string, len(segments))
+	for i, seg := range segments {
+		switch {
+		case seg == "#":
+			if i != len(segments)-1 {
+				return "", nil, invar.ErrInvalidParams
+			}
+			levels[i] = seg
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") && len(seg) > 2:
+			levels[i] = "+"
+		case seg != "+" && strings.ContainsAny(seg, "+#{}"):
+			return "", nil, invar.ErrInvalidParams
+		default:
+			levels[i] = seg
+		}
+	}
+
+	if len(segments) > 2 && segments[0] == "$share" {
+		segments = segments[2:]
+	}
+	return strings.Join(levels, "/"), segments, nil
+}
+
+// Extract named segment values and wildcard values from topic levels.
+func matchLevels(segments, levels []string) (map[string]string, []string) {
+	params, wilds := map[string]string{}, []string{}
+	for i, seg := range segments {
+		if seg == "#" {
+			if i < len(levels) {
+				wilds = append(wilds, strings.Join(levels[i:], "/"))
+			}
+			break
+		} else if i >= len(levels) {
+			break
+		}
+
+		if seg == "+" {
+			wilds = append(wilds, levels[i])
+		} else if strings.HasPrefix(seg, "{") {
+			params[seg[1:len(seg)-1]] = levels[i]
+		}
+	}
+	return params, wilds
+}
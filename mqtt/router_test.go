@@ -0,0 +1,169 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/mqtt, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Test parsePattern to subscribe filter and route segments.
+func TestParsePattern(t *testing.T) {
+	cases := []struct {
+		Case     string
+		Pattern  string
+		Filter   string
+		Segments []string
+		Invalid  bool
+	}{
+		{"Plain topic", "devices/state", "devices/state", []string{"devices", "state"}, false},
+		{"Named segment", "devices/{id}/telemetry", "devices/+/telemetry", []string{"devices", "{id}", "telemetry"}, false},
+		{"Multi named", "{org}/devices/{id}", "+/devices/+", []string{"{org}", "devices", "{id}"}, false},
+		{"Single wildcard", "devices/+/state", "devices/+/state", []string{"devices", "+", "state"}, false},
+		{"Multi wildcard", "devices/{id}/#", "devices/+/#", []string{"devices", "{id}", "#"}, false},
+		{"Only multi wildcard", "#", "#", []string{"#"}, false},
+		{"Shared subscription", "$share/group/devices/{id}/state", "$share/group/devices/+/state", []string{"devices", "{id}", "state"}, false},
+		{"Shared multi wildcard", "$share/group/#", "$share/group/#", []string{"#"}, false},
+		{"Empty pattern", "", "", nil, true},
+		{"Multi wildcard not last", "devices/#/state", "", nil, true},
+		{"Empty name", "devices/{}/state", "", nil, true},
+		{"Partial wildcard", "devices/a+/state", "", nil, true},
+		{"Partial name", "devices/{id}x/state", "", nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			filter, segments, err := parsePattern(c.Pattern)
+			if c.Invalid {
+				if err != invar.ErrInvalidParams {
+					t.Fatal("Pattern:", c.Pattern, "should be invalid, err:", err)
+				}
+				return
+			}
+
+			if err != nil || filter != c.Filter || !slices.Equal(segments, c.Segments) {
+				t.Fatal("Pattern:", c.Pattern, "filter:", filter, "segments:", segments, "err:", err)
+			}
+		})
+	}
+}
+
+// Test matchLevels to extract named and wildcard values of topic.
+func TestMatchLevels(t *testing.T) {
+	cases := []struct {
+		Case    string
+		Pattern string
+		Topic   string
+		Params  map[string]string
+		Wilds   []string
+	}{
+		{"Plain topic", "devices/state", "devices/state", map[string]string{}, []string{}},
+		{"Named segment", "devices/{id}/telemetry", "devices/d1/telemetry", map[string]string{"id": "d1"}, []string{}},
+		{"Multi named", "{org}/devices/{id}", "wengold/devices/d1", map[string]string{"org": "wengold", "id": "d1"}, []string{}},
+		{"Single wildcard", "devices/+/state", "devices/d1/state", map[string]string{}, []string{"d1"}},
+		{"Multi wildcard", "devices/{id}/#", "devices/d1/a/b", map[string]string{"id": "d1"}, []string{"a/b"}},
+		{"Multi wildcard of parent", "devices/{id}/#", "devices/d1", map[string]string{"id": "d1"}, []string{}},
+		{"Mixed wildcards", "+/{id}/#", "devices/d1/state", map[string]string{"id": "d1"}, []string{"devices", "state"}},
+		{"Shared subscription", "$share/group/devices/{id}/state", "devices/d1/state", map[string]string{"id": "d1"}, []string{}},
+		{"Shared multi wildcard", "$share/group/#", "devices/d1/state", map[string]string{}, []string{"devices/d1/state"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			_, segments, err := parsePattern(c.Pattern)
+			if err != nil {
+				t.Fatal("Parse pattern:", c.Pattern, "err:", err)
+			}
+
+			params, wilds := matchLevels(segments, strings.Split(c.Topic, "/"))
+			if !maps.Equal(params, c.Params) || !slices.Equal(wilds, c.Wilds) {
+				t.Fatal("Topic:", c.Topic, "params:", params, "wilds:", wilds)
+			}
+		})
+	}
+}
+
+// Connected mqtt client which subscribe failed.
+type failedClient struct{ mq.Client }
+
+// Completed token with subscribe error.
+type failedToken struct{}
+
+func (c *failedClient) IsConnectionOpen() bool { return true }
+func (c *failedClient) Subscribe(topic string, qos byte, callback mq.MessageHandler) mq.Token {
+	return &failedToken{}
+}
+
+func (t *failedToken) Wait() bool                     { return true }
+func (t *failedToken) WaitTimeout(time.Duration) bool { return true }
+func (t *failedToken) Done() <-chan struct{}          { return closedChan }
+func (t *failedToken) Error() error                   { return invar.ErrInvalidState }
+
+// Closed channel of completed token.
+var closedChan = func() chan struct{} { ch := make(chan struct{}); close(ch); return ch }()
+
+// Test Router.Handle keep the exist routes when subscribe failed or qos unmatched.
+func TestRouterHandle(t *testing.T) {
+	r := NewRouter(&MqttStub{Client: &failedClient{}})
+	handler := func(c *Context) error { return nil }
+
+	// Add a subscribed route of filter 'devices/+/state'.
+	exist := &route{pattern: "devices/+/state"}
+	ready := make(chan struct{})
+	close(ready)
+	r.filters["devices/+/state"] = &routeFilter{routes: []*route{exist}, qos: 1, ready: ready}
+
+	if err := r.Handle("devices/{id}/state", handler, &RouteOptions{Qos: 2}); err != invar.ErrInvalidParams {
+		t.Fatal("Unmatched qos should be rejected, err:", err)
+	} else if rf := r.filters["devices/+/state"]; len(rf.routes) != 1 || rf.routes[0] != exist {
+		t.Fatal("Exist routes changed by rejected route")
+	}
+
+	if err := r.Handle("devices/{id}/state", handler, &RouteOptions{Qos: 1}); err != nil {
+		t.Fatal("Add route to subscribed filter, err:", err)
+	} else if rf := r.filters["devices/+/state"]; len(rf.routes) != 2 || rf.routes[0] != exist {
+		t.Fatal("Route not added to subscribed filter")
+	}
+
+	if err := r.Handle("devices/{id}/telemetry", handler); err == nil {
+		t.Fatal("Subscribe should failed by client")
+	} else if _, ok := r.filters["devices/+/telemetry"]; ok {
+		t.Fatal("Failed filter not removed")
+	} else if _, ok := r.stub.subs["devices/+/telemetry"]; ok {
+		t.Fatal("Failed filter remembered to resubscribe")
+	} else if rf := r.filters["devices/+/state"]; len(rf.routes) != 2 {
+		t.Fatal("Other filter routes changed by failed route")
+	}
+}
+
+// Test Router.Handle on offline stub, the filter subscribed after connected.
+func TestRouterDeferred(t *testing.T) {
+	r := NewRouter(&MqttStub{}) // nil client defer subscribing.
+	if err := r.Handle("devices/{id}/state", func(c *Context) error { return nil }); err != nil {
+		t.Fatal("Deferred subscribe should not failed, err:", err)
+	} else if rf := r.filters["devices/+/state"]; rf == nil || len(rf.routes) != 1 {
+		t.Fatal("Deferred route not kept")
+	} else if _, ok := r.stub.subs["devices/+/state"]; !ok {
+		t.Fatal("Deferred filter not remembered to subscribe")
+	}
+}
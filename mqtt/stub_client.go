@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 
 	"github.com/astaxie/beego"
 	mq "github.com/eclipse/paho.mqtt.golang"
@@ -59,14 +60,21 @@ import (
 //		return
 //	}
 type MqttStub struct {
-	Options           *Options                 // Broker host and port, login secure datas, client id
-	Client            mq.Client                // MQTT client instance
-	ConnectHandler    mq.OnConnectHandler      // Connect callback handler
-	DisconnectHandler mq.ConnectionLostHandler // Disconnect callback handler
-	ReconnectHandler  mq.ReconnectHandler      // Reconnect callback handler
-	MessageHandler    mq.MessageHandler        // Default publish message callback handler
-	qos               byte                     // The default qos for publish or subscribe
-	remain            bool                     // The default remain flag
+	Options           *Options                  // Broker host and port, login secure datas, client id
+	Client            mq.Client                 // MQTT client instance
+	ConnectHandler    mq.OnConnectHandler       // Connect callback handler
+	DisconnectHandler mq.ConnectionLostHandler  // Disconnect callback handler
+	ReconnectHandler  mq.ReconnectHandler       // Reconnect callback handler
+	MessageHandler    mq.MessageHandler         // Default publish message callback handler
+	qos               byte                      // The default qos for publish or subscribe
+	remain            bool                      // The default remain flag
+	lock              sync.Mutex                // Lock for subscriptions
+	subs              map[string]*subscription  // Subscriptions to restore after reconnected
+	buffer            *publishBuffer            // Offline publish buffer, see SetBuffer()
+	rpcLock           sync.Mutex                // Lock for rpc calls
+	replyTopic        string                    // Reply topic of rpc calls
+	replying          chan struct{}             // Closed when reply topic subscribing done
+	calls             map[string]chan *Envelope // Waiting rpc calls by correlation id
 }
 
 // Singleton mqtt stub instance
@@ -172,10 +180,26 @@ func (stub *MqttStub) GetConnOptions(mode ...string) *mq.ClientOptions {
 	return options
 }
 
-// New client from given options and connect with broker
+// New client from given options and connect with broker, the subscriptions
+// will be restored and the buffered messages flushed after reconnected.
+//
+// # WARNING:
+//   - The client keep retrying to connect in background when offline buffer
+//     enabled by SetBuffer(), and return invar.ErrClientOffline when not
+//     connected in the connect timeout of options.
 func (stub *MqttStub) Connect(opt *mq.ClientOptions) error {
-	stub.Client = mq.NewClient(opt)
-	if token := stub.Client.Connect(); token.Wait() && token.Error() != nil {
+	o := *opt // copy to not wrap the connect handler of options repeatedly.
+	o.SetOnConnectHandler(stub.onConnected(opt.OnConnect))
+	if stub.buffer != nil {
+		o.SetConnectRetry(true)
+	}
+
+	stub.Client = mq.NewClient(&o)
+	token := stub.Client.Connect()
+	if stub.buffer != nil && !token.WaitTimeout(o.ConnectTimeout) {
+		mqxlog.W("Connect mqtt client timeout, retrying in background")
+		return invar.ErrClientOffline
+	} else if token.Wait() && token.Error() != nil {
 		stub.Client = nil
 		mqxlog.E("Connect mqtt client, err:", token.Error())
 		return token.Error()
@@ -198,13 +222,9 @@ func (stub *MqttStub) Publish(topic string, data any, Qos ...byte) error {
 // # NOTICE:
 //
 // The data will encode as json bytes array if value type is Struct,
-// Pointer or map, or instead nil data to empty bytes array.
+// Pointer or map, or instead nil data to empty bytes array. And it will
+// be buffered when client offline if enabled buffer by SetBuffer().
 func (stub *MqttStub) PublishOptions(topic string, data any, remain bool, Qos ...byte) error {
-	if stub.Client == nil {
-		mqxlog.E("Abort publish topic:", topic, "on nil client!!")
-		return invar.ErrInvalidClient
-	}
-
 	var payload any
 	if data != nil {
 		switch reflect.ValueOf(data).Kind() {
@@ -226,6 +246,13 @@ func (stub *MqttStub) PublishOptions(topic string, data any, remain bool, Qos ..
 		qosv = Qos[0]
 	}
 
+	if buffered, err := stub.bufferPublish(topic, qosv, remain, payload); buffered {
+		return err
+	} else if stub.Client == nil {
+		mqxlog.E("Abort publish topic:", topic, "on nil client!!")
+		return invar.ErrInvalidClient
+	}
+
 	token := stub.Client.Publish(topic, qosv, remain, payload)
 	if token.Wait() && token.Error() != nil {
 		mqxlog.E("Publish topic:", topic, "err:", token.Error())
@@ -236,20 +263,28 @@ func (stub *MqttStub) PublishOptions(topic string, data any, remain bool, Qos ..
 	return nil
 }
 
-// Subscribe given topic and set callback
+// Subscribe given topic and set callback, the topic will be remembered
+// and subscribed again after connected or reconnected.
+//
+// # WARNING:
+//   - The subscribing deferred until connected when client nil or offline,
+//     and return nil as the topic remembered.
+//   - The topic not remembered when subscribe failed.
 func (stub *MqttStub) Subscribe(topic string, hanlder mq.MessageHandler, Qos ...byte) error {
-	if stub.Client == nil {
-		mqxlog.E("Abort subscribe topic:", topic, "on nil client!!")
-		return invar.ErrInvalidClient
-	}
-
 	qosv := stub.qos
 	if len(Qos) > 0 && Qos[0] > 0 && Qos[0] <= 2 {
 		qosv = Qos[0]
 	}
 
+	stub.rememberSubscribe(topic, qosv, hanlder)
+	if stub.Client == nil || !stub.Client.IsConnectionOpen() {
+		mqxlog.W("Deferred subscribe topic:", topic, "until connected")
+		return nil
+	}
+
 	token := stub.Client.Subscribe(topic, qosv, hanlder)
 	if token.Wait() && token.Error() != nil {
+		stub.forgetSubscribe(topic)
 		mqxlog.E("Subscribe topic:", topic, "err:", token.Error())
 		return token.Error()
 	}
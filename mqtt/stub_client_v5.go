@@ -0,0 +1,525 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/secure"
+	"github.com/wengoldx/xcore/utils"
+)
+
+// Options of MQTT v5 publish.
+type V5Publish struct {
+	Qos           byte              // Publish qos, default use stub qos
+	Retain        bool              // Retain flag
+	ContentType   string            // Content type of payload, such as 'application/json'
+	ResponseTopic string            // Response topic for request message
+	Correlation   []byte            // Correlation data to match request and response
+	Expiry        time.Duration     // Message expiry interval, default 0 never expired
+	Properties    map[string]string // User properties
+}
+
+// Reason code error of MQTT v5 acks, such as PUBACK, SUBACK.
+type ReasonError struct {
+	Code   byte   // Reason code over 0x80
+	Reason string // Reason string of broker
+}
+
+// MQTT v5 message handler.
+type V5Handler func(msg *paho.Publish)
+
+// MQTT v5 stub to manager connection, it auto reconnect to broker and
+// restore subscriptions after reconnected.
+//
+// # USAGE:
+//
+//	stub, err := mqtt.NewV5Client(data)
+//	if err != nil {
+//		mqxlog.E("Connect client err:", err)
+//		return
+//	}
+//
+//	// Load balance messages between group members by shared subscription.
+//	stub.SubscribeShared("workers", "devices/+/telemetry", func(msg *paho.Publish) {
+//		logger.I("Received:", msg.Topic, msg.Properties.User.Get("trace"))
+//	})
+//
+//	// Request and wait response published by responder as stub.Respond(req, data).
+//	resp, err := stub.Request(ctx, "devices/d1/cmd", &Command{Action: "reboot"})
+type V5Stub struct {
+	Options        *Options                                         // Broker host and port, login secure datas, client id
+	Conn           *autopaho.ConnectionManager                      // Connection manager of MQTT v5 client
+	ConnectHandler func(*autopaho.ConnectionManager, *paho.Connack) // Connect and reconnect callback handler
+	qos            byte                                             // The default qos for publish or subscribe
+	router         *paho.StandardRouter                             // Router of subscribed handlers
+	lock           sync.Mutex                                       // Lock for subscriptions and requests
+	subs           map[string]paho.SubscribeOptions                 // Subscriptions to restore after reconnected
+	handlers       map[string]paho.MessageHandler                   // Registered handlers of subscriptions
+	replyTopic     string                                           // Response topic of requests
+	requests       map[string]chan *paho.Publish                    // Waiting requests by correlation id
+	cancel         context.CancelFunc                               // Cancel connection manager
+}
+
+// Singleton mqtt v5 stub instance
+var mqttV5Stub *V5Stub
+
+// Default connect timeout of MQTT v5 client.
+const v5ConnectTimeout = 10 * time.Second
+
+// Return Mqtt v5 global singleton
+func SingletonV5() *V5Stub {
+	if mqttV5Stub == nil {
+		mqttV5Stub = &V5Stub{
+			Options: &Options{}, router: paho.NewStandardRouter(),
+			subs: make(map[string]paho.SubscribeOptions), handlers: make(map[string]paho.MessageHandler),
+			requests: make(map[string]chan *paho.Publish),
+		}
+	}
+	return mqttV5Stub
+}
+
+// Create the MQTT v5 client and connect with broker, the configs same as
+// NewClient() which maybe json string from Nacos or Options object refrence.
+func NewV5Client(configs any, server ...string) (*V5Stub, error) {
+	svr := utils.Variable(server, beego.BConfig.AppName)
+	stub := SingletonV5()
+
+	switch cfgs := configs.(type) {
+	case string:
+		parser := &MqttStub{Options: &Options{ClientID: stub.Options.ClientID}}
+		if err := parser.parseConfig(cfgs, svr); err != nil {
+			return nil, err
+		}
+		stub.Options = parser.Options
+	case *Options:
+		stub.Options = cfgs
+		if stub.Options.ClientID == "" {
+			stub.Options.ClientID = svr + "." + secure.NewCode()
+		}
+	default:
+		return nil, invar.ErrInvalidConfigs
+	}
+
+	if err := stub.Connect(stub.GetConnConfig()); err != nil {
+		mqxlog.E("New", svr, "mqtt v5 client err:", err)
+		return nil, err
+	}
+	return stub, nil
+}
+
+// Set default qos of MQTT v5 stub.
+func (stub *V5Stub) SetQos(qos byte) *V5Stub {
+	stub.qos = qos
+	return stub
+}
+
+// Create MQTT v5 connect config, default connection protocol using tcp,
+// you can set mode 'tls' and cert files to using ssl protocol.
+func (stub *V5Stub) GetConnConfig(mode ...string) autopaho.ClientConfig {
+	scheme, cfg := "mqtt", autopaho.ClientConfig{}
+	if len(mode) > 0 && mode[0] == "tls" {
+		scheme = "tls"
+		cfg.TlsCfg = (&MqttStub{Options: stub.Options}).newTLSConfig()
+	}
+
+	broker := &url.URL{Scheme: scheme, Host: fmt.Sprintf("%s:%v", stub.Options.Host, stub.Options.Port)}
+	cfg.ServerUrls = []*url.URL{broker}
+	cfg.KeepAlive = 30
+	cfg.ConnectTimeout = v5ConnectTimeout
+	cfg.ReconnectBackoff = autopaho.NewExponentialBackoff(time.Second, time.Minute, 2*time.Second, 2)
+	if user := stub.Options.User; user != nil {
+		cfg.ConnectUsername, cfg.ConnectPassword = user.Account, []byte(user.Password)
+	}
+
+	cfg.ClientID = stub.Options.ClientID
+	cfg.OnConnectionUp = stub.onConnectionUp
+	cfg.OnConnectError = func(err error) { mqxlog.E("Connect mqtt v5 client, err:", err) }
+	cfg.OnServerDisconnect = func(d *paho.Disconnect) {
+		mqxlog.W("Disconnected by broker, reason code:", d.ReasonCode)
+	}
+	cfg.OnPublishReceived = []func(paho.PublishReceived) (bool, error){stub.onReceived}
+	return cfg
+}
+
+// Create connection manager and wait the first connection, it will keep
+// reconnecting in background after connected.
+func (stub *V5Stub) Connect(cfg autopaho.ClientConfig) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := autopaho.NewConnection(ctx, cfg)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	wctx, wcancel := context.WithTimeout(ctx, v5ConnectTimeout)
+	defer wcancel()
+	if err := conn.AwaitConnection(wctx); err != nil {
+		cancel()
+		mqxlog.E("Connect mqtt v5 client, err:", err)
+		return err
+	}
+
+	stub.Conn, stub.cancel = conn, cancel
+	return nil
+}
+
+// Disconnect from broker and stop reconnecting.
+func (stub *V5Stub) Disconnect(ctx context.Context) error {
+	if stub.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	defer stub.cancel()
+	return stub.Conn.Disconnect(ctx)
+}
+
+// Publish indicate topic message, the Qos can be set current call in 0 ~ 2
+func (stub *V5Stub) Publish(topic string, data any, Qos ...byte) error {
+	opts := &V5Publish{Qos: utils.Variable(Qos, stub.qos)}
+	return stub.PublishOptions(context.Background(), topic, data, opts)
+}
+
+// Publish indicate topic message with MQTT v5 properties, the data encode
+// same as MqttStub.PublishOptions().
+func (stub *V5Stub) PublishOptions(ctx context.Context, topic string, data any, opts *V5Publish) error {
+	if stub.Conn == nil {
+		mqxlog.E("Abort publish topic:", topic, "on nil client!!")
+		return invar.ErrInvalidClient
+	}
+
+	payload, err := encodePayload(data)
+	if err != nil {
+		return err
+	}
+
+	pub := &paho.Publish{Topic: topic, Payload: payload, QoS: stub.qos}
+	if opts != nil {
+		if opts.Qos > 0 && opts.Qos <= 2 {
+			pub.QoS = opts.Qos
+		}
+		pub.Retain, pub.Properties = opts.Retain, opts.properties()
+	}
+
+	resp, err := stub.Conn.Publish(ctx, pub)
+	if resp != nil && resp.ReasonCode >= 0x80 {
+		err = newReasonError(resp.ReasonCode, resp.Properties)
+	}
+	if err != nil {
+		mqxlog.E("Publish topic:", topic, "err:", err)
+		return err
+	}
+
+	mqxlog.I("Published topic:", topic)
+	return nil
+}
+
+// Subscribe given topic and set callback, the topic will be remembered and
+// subscribed again after reconnected.
+//
+// # WARNING:
+//   - The callback replace the exist one when subscribe the same topic again.
+//   - The previous subscription restored when the broker denied subscribing.
+func (stub *V5Stub) Subscribe(topic string, handler V5Handler, Qos ...byte) error {
+	if stub.Conn == nil {
+		mqxlog.E("Abort subscribe topic:", topic, "on nil client!!")
+		return invar.ErrInvalidClient
+	}
+
+	opt := paho.SubscribeOptions{Topic: topic, QoS: utils.Variable(Qos, stub.qos)}
+	stub.lock.Lock()
+	prevopt, subscribed := stub.subs[topic]
+	prevhandler := stub.handlers[topic]
+	stub.subs[topic], stub.handlers[topic] = opt, paho.MessageHandler(handler)
+	stub.router.UnregisterHandler(topic) // the router appends handlers of same topic
+	stub.router.RegisterHandler(topic, paho.MessageHandler(handler))
+	stub.lock.Unlock()
+
+	if err := stub.subscribe(context.Background(), opt); err != nil {
+		if reason := (*ReasonError)(nil); errors.As(err, &reason) {
+			stub.lock.Lock()
+			stub.router.UnregisterHandler(topic)
+			if subscribed { // keep the previous subscription of topic
+				stub.subs[topic], stub.handlers[topic] = prevopt, prevhandler
+				if prevhandler != nil {
+					stub.router.RegisterHandler(topic, prevhandler)
+				}
+			} else { // not restore the subscription denied by broker
+				delete(stub.subs, topic)
+				delete(stub.handlers, topic)
+			}
+			stub.lock.Unlock()
+		}
+		mqxlog.E("Subscribe topic:", topic, "err:", err)
+		return err
+	}
+	mqxlog.I("Subscribed topic:", topic)
+	return nil
+}
+
+// Subscribe given topic as shared subscription of group, the messages will
+// be load balanced between the clients of same group.
+func (stub *V5Stub) SubscribeShared(group, topic string, handler V5Handler, Qos ...byte) error {
+	return stub.Subscribe("$share/"+group+"/"+topic, handler, Qos...)
+}
+
+// Unsubscribe topics and forget them from restoring after reconnected.
+func (stub *V5Stub) Unsubscribe(topics ...string) error {
+	if stub.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	stub.lock.Lock()
+	for _, topic := range topics {
+		delete(stub.subs, topic)
+		delete(stub.handlers, topic)
+		stub.router.UnregisterHandler(topic)
+	}
+	stub.lock.Unlock()
+
+	ack, err := stub.Conn.Unsubscribe(context.Background(), &paho.Unsubscribe{Topics: topics})
+	if ack != nil {
+		for _, code := range ack.Reasons {
+			if code >= 0x80 {
+				err = &ReasonError{Code: code}
+				break
+			}
+		}
+	}
+	if err != nil {
+		mqxlog.E("Unsubscribe topics:", topics, "err:", err)
+		return err
+	}
+	mqxlog.I("Unsubscribed topics:", topics)
+	return nil
+}
+
+// Publish request with response topic and correlation data, and wait the
+// response until the context done.
+//
+// # USAGE:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	resp, err := stub.Request(ctx, "devices/d1/cmd", &Command{Action: "reboot"})
+//	if err != nil {
+//		return err
+//	}
+//	logger.I("Device replied:", string(resp.Payload))
+func (stub *V5Stub) Request(ctx context.Context, topic string, data any, opts ...*V5Publish) (*paho.Publish, error) {
+	if err := stub.ensureReplyTopic(ctx); err != nil {
+		return nil, err
+	}
+
+	opt := &V5Publish{Qos: stub.qos}
+	if len(opts) > 0 && opts[0] != nil {
+		copied := *opts[0]
+		opt = &copied
+	}
+
+	correlation, resp := secure.NewSUID(), make(chan *paho.Publish, 1)
+	stub.lock.Lock()
+	opt.ResponseTopic, opt.Correlation = stub.replyTopic, []byte(correlation)
+	stub.requests[correlation] = resp
+	stub.lock.Unlock()
+
+	defer func() {
+		stub.lock.Lock()
+		delete(stub.requests, correlation)
+		stub.lock.Unlock()
+	}()
+
+	if err := stub.PublishOptions(ctx, topic, data, opt); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-resp:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Publish response to the response topic of request with correlation data.
+func (stub *V5Stub) Respond(req *paho.Publish, data any, opts ...*V5Publish) error {
+	if req.Properties == nil || req.Properties.ResponseTopic == "" {
+		return invar.ErrInvalidParams
+	}
+
+	opt := &V5Publish{Qos: req.QoS}
+	if len(opts) > 0 && opts[0] != nil {
+		copied := *opts[0]
+		opt = &copied
+	}
+
+	opt.Correlation = req.Properties.CorrelationData
+	return stub.PublishOptions(context.Background(), req.Properties.ResponseTopic, data, opt)
+}
+
+// Return the reason code error string.
+func (e *ReasonError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("reason code 0x%02x: %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("reason code 0x%02x", e.Code)
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Restore subscriptions after connected, the subscriptions lost when the
+// broker not keep session.
+func (stub *V5Stub) onConnectionUp(conn *autopaho.ConnectionManager, ack *paho.Connack) {
+	stub.Conn = conn
+	mqxlog.I("Connected mqtt v5 as client:", stub.Options.ClientID)
+
+	stub.lock.Lock()
+	subs := make([]paho.SubscribeOptions, 0, len(stub.subs))
+	for _, opt := range stub.subs {
+		subs = append(subs, opt)
+	}
+	stub.lock.Unlock()
+
+	if len(subs) > 0 {
+		if err := stub.subscribe(context.Background(), subs...); err != nil {
+			mqxlog.E("Resubscribe topics, err:", err)
+		}
+	}
+
+	if stub.ConnectHandler != nil {
+		stub.ConnectHandler(conn, ack)
+	}
+}
+
+// Dispatch the received message to waiting request or subscribed handlers.
+func (stub *V5Stub) onReceived(pr paho.PublishReceived) (bool, error) {
+	msg := pr.Packet
+	stub.lock.Lock()
+	replyto := stub.replyTopic
+	stub.lock.Unlock()
+
+	if props := msg.Properties; props != nil && len(props.CorrelationData) > 0 && msg.Topic == replyto {
+		stub.lock.Lock()
+		resp, ok := stub.requests[string(props.CorrelationData)]
+		stub.lock.Unlock()
+
+		if ok {
+			select {
+			case resp <- msg:
+			default: // duplicated response
+			}
+		}
+		return true, nil
+	}
+
+	stub.router.Route(msg.Packet())
+	return true, nil
+}
+
+// Subscribe topics and check the reason codes of suback.
+func (stub *V5Stub) subscribe(ctx context.Context, opts ...paho.SubscribeOptions) error {
+	ack, err := stub.Conn.Subscribe(ctx, &paho.Subscribe{Subscriptions: opts})
+	if ack != nil {
+		for _, code := range ack.Reasons {
+			if code >= 0x80 {
+				return newReasonError(code, ack.Properties)
+			}
+		}
+	}
+	return err
+}
+
+// Subscribe the response topic of requests once.
+func (stub *V5Stub) ensureReplyTopic(ctx context.Context) error {
+	if stub.Conn == nil {
+		return invar.ErrInvalidClient
+	}
+
+	stub.lock.Lock()
+	if stub.replyTopic != "" {
+		stub.lock.Unlock()
+		return nil
+	}
+
+	topic := "replies/" + stub.Options.ClientID
+	opt := paho.SubscribeOptions{Topic: topic, QoS: 1}
+	stub.subs[topic], stub.replyTopic = opt, topic
+	stub.lock.Unlock()
+
+	if err := stub.subscribe(ctx, opt); err != nil {
+		stub.lock.Lock()
+		delete(stub.subs, topic)
+		stub.replyTopic = ""
+		stub.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Return MQTT v5 publish properties.
+func (opts *V5Publish) properties() *paho.PublishProperties {
+	props := &paho.PublishProperties{
+		ContentType: opts.ContentType, ResponseTopic: opts.ResponseTopic, CorrelationData: opts.Correlation,
+	}
+	if opts.Expiry > 0 {
+		expiry := uint32(opts.Expiry / time.Second)
+		props.MessageExpiry = &expiry
+	}
+	for key, value := range opts.Properties {
+		props.User.Add(key, value)
+	}
+	return props
+}
+
+// Create reason error with reason string of ack properties.
+func newReasonError(code byte, props any) *ReasonError {
+	err := &ReasonError{Code: code}
+	switch p := props.(type) {
+	case *paho.PublishResponseProperties:
+		if p != nil {
+			err.Reason = p.ReasonString
+		}
+	case *paho.SubackProperties:
+		if p != nil {
+			err.Reason = p.ReasonString
+		}
+	}
+	return err
+}
+
+// Encode payload as bytes, the data encode as json if value type is Struct,
+// Pointer or map, or instead nil data to empty bytes array.
+func encodePayload(data any) ([]byte, error) {
+	if data == nil {
+		return []byte{}, nil
+	} else if payload, err := payloadBytes(data); err == nil {
+		return payload, nil
+	}
+
+	switch reflect.ValueOf(data).Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Map:
+		return json.Marshal(data)
+	}
+	return []byte(fmt.Sprint(data)), nil
+}
@@ -0,0 +1,411 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Drop policy of offline publish buffer when it full.
+type DropPolicy int
+
+const (
+	DropOldest DropPolicy = iota // Drop the oldest buffered message
+	DropNewest                   // Drop the new publishing message and return invar.ErrClientOffline
+)
+
+// Options of offline publish buffer.
+type BufferOptions struct {
+	MaxSize   int        // Max messages buffered in memory, default 1000
+	SpillFile string     // File to spill messages when memory full, default not spill
+	MaxSpill  int        // Max messages spilled to file, default 10000
+	Policy    DropPolicy // Drop policy when buffer full, default DropOldest
+}
+
+// Statistics of offline publish buffer.
+type BufferStats struct {
+	Pending  int   // Messages waiting to flush, both in memory and spill file
+	Spilled  int   // Messages waiting in spill file
+	Buffered int64 // Total buffered messages
+	Flushed  int64 // Total flushed messages after reconnected
+	Dropped  int64 // Total dropped messages when buffer full
+}
+
+// Subscribed topic to restore after reconnected.
+type subscription struct {
+	qos     byte
+	handler mq.MessageHandler
+}
+
+// Buffered publish message.
+type bufferedMsg struct {
+	Topic   string `json:"topic"`
+	Qos     byte   `json:"qos"`
+	Retain  bool   `json:"retain"`
+	Payload []byte `json:"payload"`
+}
+
+// Offline publish buffer, the messages in memory queue always older than
+// the messages in spill file.
+type publishBuffer struct {
+	opts     *BufferOptions
+	lock     sync.Mutex
+	queue    []*bufferedMsg
+	spilled  int
+	flushing bool
+	stats    BufferStats
+}
+
+// Enable offline publish buffer, the messages published while disconnected
+// will be buffered and flushed in order once reconnected, set nil to disable.
+//
+// # USAGE:
+//
+//	stub := mqtt.Singleton()
+//	stub.SetBuffer(&mqtt.BufferOptions{MaxSize: 500, SpillFile: "/data/mqtt.spill"})
+//	if err := mqtt.NewClient(data); err == invar.ErrClientOffline {
+//		// The client retrying in background, and the publishing
+//		// will be buffered until connected.
+//	}
+//
+// # WARNING:
+//   - Call it before connect to keep the client retrying when offline.
+//   - The messages remain in spill file will be flushed after restart.
+func (stub *MqttStub) SetBuffer(opts *BufferOptions) error {
+	if opts == nil {
+		stub.buffer = nil
+		return nil
+	}
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1000
+	}
+	if opts.MaxSpill <= 0 {
+		opts.MaxSpill = 10000
+	}
+
+	buffer := &publishBuffer{opts: opts}
+	if opts.SpillFile != "" {
+		msgs, err := buffer.readSpill()
+		if err != nil {
+			return err
+		}
+		buffer.spilled = len(msgs)
+	}
+	stub.buffer = buffer
+	return nil
+}
+
+// Return the statistics of offline publish buffer.
+func (stub *MqttStub) BufferStats() BufferStats {
+	if b := stub.buffer; b != nil {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		stats := b.stats
+		stats.Pending, stats.Spilled = len(b.queue)+b.spilled, b.spilled
+		return stats
+	}
+	return BufferStats{}
+}
+
+// Unsubscribe topics and forget them from restoring after reconnected.
+func (stub *MqttStub) Unsubscribe(topics ...string) error {
+	stub.lock.Lock()
+	for _, topic := range topics {
+		delete(stub.subs, topic)
+	}
+	stub.lock.Unlock()
+
+	if stub.Client == nil {
+		return invar.ErrInvalidClient
+	}
+
+	token := stub.Client.Unsubscribe(topics...)
+	if token.Wait() && token.Error() != nil {
+		mqxlog.E("Unsubscribe topics:", topics, "err:", token.Error())
+		return token.Error()
+	}
+	mqxlog.I("Unsubscribed topics:", topics)
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Return connect handler to restore subscriptions and flush buffered
+// messages before call the given handler.
+func (stub *MqttStub) onConnected(handler mq.OnConnectHandler) mq.OnConnectHandler {
+	return func(client mq.Client) {
+		stub.restoreSubscribes(client)
+		if handler != nil {
+			handler(client)
+		}
+		stub.flushBuffer(client)
+	}
+}
+
+// Remember subscribed topic to restore after reconnected.
+func (stub *MqttStub) rememberSubscribe(topic string, qos byte, handler mq.MessageHandler) {
+	stub.lock.Lock()
+	defer stub.lock.Unlock()
+	if stub.subs == nil {
+		stub.subs = make(map[string]*subscription)
+	}
+	stub.subs[topic] = &subscription{qos: qos, handler: handler}
+}
+
+// Forget the subscribed topic which subscribe failed.
+func (stub *MqttStub) forgetSubscribe(topic string) {
+	stub.lock.Lock()
+	defer stub.lock.Unlock()
+	delete(stub.subs, topic)
+}
+
+// Subscribe the remembered topics again, the subscriptions lost when the
+// broker not keep session.
+func (stub *MqttStub) restoreSubscribes(client mq.Client) {
+	stub.lock.Lock()
+	subs := make(map[string]*subscription, len(stub.subs))
+	for topic, sub := range stub.subs {
+		subs[topic] = sub
+	}
+	stub.lock.Unlock()
+
+	for topic, sub := range subs {
+		token := client.Subscribe(topic, sub.qos, sub.handler)
+		if token.Wait() && token.Error() != nil {
+			mqxlog.E("Resubscribe topic:", topic, "err:", token.Error())
+			continue
+		}
+		mqxlog.I("Resubscribed topic:", topic)
+	}
+}
+
+// Buffer the message when client disconnected or buffer not flushed, it
+// return false when the message not buffered and should publish directly.
+func (stub *MqttStub) bufferPublish(topic string, qos byte, remain bool, payload any) (bool, error) {
+	b := stub.buffer
+	if b == nil {
+		return false, nil
+	}
+
+	connected := stub.Client != nil && stub.Client.IsConnectionOpen()
+	b.lock.Lock()
+	if connected && !b.flushing && len(b.queue)+b.spilled == 0 {
+		b.lock.Unlock()
+		return false, nil
+	}
+
+	data, err := payloadBytes(payload)
+	if err == nil {
+		err = b.push(&bufferedMsg{Topic: topic, Qos: qos, Retain: remain, Payload: data})
+	}
+	flushing := b.flushing
+	b.lock.Unlock()
+
+	if err != nil {
+		mqxlog.E("Buffer topic:", topic, "err:", err)
+		return true, err
+	} else if connected && !flushing {
+		go stub.flushBuffer(stub.Client)
+	}
+	mqxlog.W("Buffered topic:", topic, "on offline client")
+	return true, nil
+}
+
+// Publish buffered messages in order until buffer empty or publish failed.
+func (stub *MqttStub) flushBuffer(client mq.Client) {
+	b := stub.buffer
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	if b.flushing {
+		b.lock.Unlock()
+		return
+	}
+	b.flushing = true
+	b.lock.Unlock()
+
+	for {
+		b.lock.Lock()
+		msg, err := b.front()
+		if msg == nil || err != nil {
+			b.flushing = false
+			b.lock.Unlock()
+			if err != nil {
+				mqxlog.E("Load spilled messages, err:", err)
+			}
+			return
+		}
+		b.lock.Unlock()
+
+		token := client.Publish(msg.Topic, msg.Qos, msg.Retain, msg.Payload)
+		if token.Wait() && token.Error() != nil {
+			b.lock.Lock()
+			b.flushing = false
+			b.lock.Unlock()
+			mqxlog.E("Flush topic:", msg.Topic, "err:", token.Error())
+			return
+		}
+
+		b.lock.Lock()
+		if len(b.queue) > 0 && b.queue[0] == msg {
+			b.queue = b.queue[1:] // it may dropped as oldest when publishing
+		}
+		b.stats.Flushed++
+		b.lock.Unlock()
+	}
+}
+
+// Push message into memory queue or spill file, and drop message by policy
+// when buffer full, the buffer must locked by caller.
+func (b *publishBuffer) push(msg *bufferedMsg) error {
+	capacity := b.opts.MaxSize
+	if b.opts.SpillFile != "" {
+		capacity += b.opts.MaxSpill
+	}
+
+	if len(b.queue)+b.spilled >= capacity {
+		b.stats.Dropped++
+		if b.opts.Policy == DropNewest {
+			return invar.ErrClientOffline
+		}
+		if _, err := b.front(); err != nil {
+			return err
+		}
+		b.queue = b.queue[1:]
+	}
+
+	if b.spilled == 0 && len(b.queue) < b.opts.MaxSize {
+		b.queue = append(b.queue, msg)
+	} else if err := b.appendSpill(msg); err != nil {
+		return err
+	} else {
+		b.spilled++
+	}
+	b.stats.Buffered++
+	return nil
+}
+
+// Return the oldest message, it load spilled messages into memory queue
+// when queue empty, the buffer must locked by caller.
+func (b *publishBuffer) front() (*bufferedMsg, error) {
+	if len(b.queue) == 0 && b.spilled > 0 {
+		msgs, err := b.readSpill()
+		if err != nil {
+			return nil, err
+		}
+
+		n := min(len(msgs), b.opts.MaxSize)
+		if err := b.writeSpill(msgs[n:]); err != nil {
+			return nil, err
+		}
+		b.queue, b.spilled = msgs[:n], len(msgs)-n
+	}
+
+	if len(b.queue) > 0 {
+		return b.queue[0], nil
+	}
+	return nil, nil
+}
+
+// Append message as json line into spill file.
+func (b *publishBuffer) appendSpill(msg *bufferedMsg) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(b.opts.SpillFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Read all messages from spill file, it return empty when file unexist.
+func (b *publishBuffer) readSpill() ([]*bufferedMsg, error) {
+	file, err := os.Open(b.opts.SpillFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	msgs := []*bufferedMsg{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		msg := &bufferedMsg{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			mqxlog.W("Skip invalid spilled message, err:", err)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, scanner.Err()
+}
+
+// Rewrite spill file by the given messages, it remove file when empty.
+func (b *publishBuffer) writeSpill(msgs []*bufferedMsg) error {
+	if len(msgs) == 0 {
+		if err := os.Remove(b.opts.SpillFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	for _, msg := range msgs {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	temp := b.opts.SpillFile + ".tmp"
+	if err := os.WriteFile(temp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, b.opts.SpillFile)
+}
+
+// Convert publish payload to bytes, it support the payload types of mqtt
+// client as string, []byte and bytes.Buffer.
+func payloadBytes(payload any) ([]byte, error) {
+	switch p := payload.(type) {
+	case []byte:
+		return p, nil
+	case string:
+		return []byte(p), nil
+	case bytes.Buffer:
+		return p.Bytes(), nil
+	case *bytes.Buffer:
+		return p.Bytes(), nil
+	}
+	return nil, invar.ErrInvalidData
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/mqtt, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Connected mqtt client which record the published payloads.
+type publishedClient struct {
+	mq.Client
+	payloads []string
+}
+
+// Completed token without error.
+type doneToken struct{}
+
+func (c *publishedClient) IsConnectionOpen() bool { return true }
+func (c *publishedClient) Publish(topic string, qos byte, retained bool, payload any) mq.Token {
+	c.payloads = append(c.payloads, string(payload.([]byte)))
+	return &doneToken{}
+}
+
+func (t *doneToken) Wait() bool                     { return true }
+func (t *doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t *doneToken) Done() <-chan struct{}          { return closedChan }
+func (t *doneToken) Error() error                   { return nil }
+
+// Buffer the given count of messages with payloads 'm0', 'm1'... on offline stub.
+func bufferMessages(t *testing.T, stub *MqttStub, count int) []error {
+	errs := []error{}
+	for i := 0; i < count; i++ {
+		buffered, err := stub.bufferPublish("devices/state", 1, false, fmt.Sprintf("m%d", i))
+		if !buffered {
+			t.Fatal("Message not buffered on offline stub")
+		}
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Test offline buffer flush messages in order across memory queue and spill file.
+func TestBufferOrder(t *testing.T) {
+	spill := filepath.Join(t.TempDir(), "mqtt.spill")
+	stub := &MqttStub{}
+	if err := stub.SetBuffer(&BufferOptions{MaxSize: 2, SpillFile: spill}); err != nil {
+		t.Fatal("Set buffer, err:", err)
+	}
+
+	bufferMessages(t, stub, 5)
+	if stats := stub.BufferStats(); stats.Pending != 5 || stats.Spilled != 3 {
+		t.Fatal("Unmatched buffer stats:", stats)
+	}
+
+	client := &publishedClient{}
+	stub.flushBuffer(client)
+	if want := []string{"m0", "m1", "m2", "m3", "m4"}; !slices.Equal(client.payloads, want) {
+		t.Fatal("Unmatched flushed order:", client.payloads)
+	} else if stats := stub.BufferStats(); stats.Pending != 0 || stats.Flushed != 5 {
+		t.Fatal("Unmatched flushed stats:", stats)
+	} else if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Fatal("Spill file not removed after flushed, err:", err)
+	}
+}
+
+// Test offline buffer drop messages by policy when buffer full.
+func TestBufferDrop(t *testing.T) {
+	cases := []struct {
+		Case     string
+		Policy   DropPolicy
+		Payloads []string
+		Errors   []error
+	}{
+		{"Drop oldest", DropOldest, []string{"m2", "m3", "m4"}, []error{nil, nil, nil, nil, nil}},
+		{"Drop newest", DropNewest, []string{"m0", "m1", "m2"}, []error{nil, nil, nil, invar.ErrClientOffline, invar.ErrClientOffline}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			stub := &MqttStub{}
+			stub.SetBuffer(&BufferOptions{MaxSize: 3, Policy: c.Policy})
+
+			if errs := bufferMessages(t, stub, 5); !slices.Equal(errs, c.Errors) {
+				t.Fatal("Unmatched buffer errors:", errs)
+			} else if stats := stub.BufferStats(); stats.Pending != 3 || stats.Dropped != 2 {
+				t.Fatal("Unmatched buffer stats:", stats)
+			}
+
+			client := &publishedClient{}
+			stub.flushBuffer(client)
+			if !slices.Equal(client.payloads, c.Payloads) {
+				t.Fatal("Unmatched flushed messages:", client.payloads)
+			}
+		})
+	}
+}
+
+// Test offline buffer reload the messages remain in spill file after restart.
+func TestBufferReload(t *testing.T) {
+	spill := filepath.Join(t.TempDir(), "mqtt.spill")
+	stub := &MqttStub{}
+	stub.SetBuffer(&BufferOptions{MaxSize: 1, SpillFile: spill})
+	bufferMessages(t, stub, 3)
+
+	// Restart and the messages in memory queue lost.
+	restarted := &MqttStub{}
+	if err := restarted.SetBuffer(&BufferOptions{MaxSize: 1, SpillFile: spill}); err != nil {
+		t.Fatal("Set buffer, err:", err)
+	} else if stats := restarted.BufferStats(); stats.Pending != 2 || stats.Spilled != 2 {
+		t.Fatal("Unmatched reloaded stats:", stats)
+	}
+
+	client := &publishedClient{}
+	restarted.flushBuffer(client)
+	if want := []string{"m1", "m2"}; !slices.Equal(client.payloads, want) {
+		t.Fatal("Unmatched reloaded messages:", client.payloads)
+	}
+}
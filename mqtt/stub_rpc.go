@@ -0,0 +1,257 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/secure"
+)
+
+// Payload envelope of rpc request and reply, it carry the correlation id
+// and reply topic for MQTT 3.1.1 which not support response properties.
+type Envelope struct {
+	ID       string          `json:"id"`                 // Correlation id of request and reply
+	ReplyTo  string          `json:"reply_to,omitempty"` // Reply topic of request
+	Deadline int64           `json:"deadline,omitempty"` // Request deadline in unix milliseconds, 0 for never
+	Data     json.RawMessage `json:"data,omitempty"`     // Json encoded request or reply data
+	Error    string          `json:"error,omitempty"`    // Error message of failed reply
+}
+
+// Error replied by rpc handler.
+type RPCError struct {
+	Message string // Error message of handler
+}
+
+// Rpc handler to return the reply data or error of request data.
+type RPCHandler func(topic string, data json.RawMessage) (any, error)
+
+// Rpc reply topic prefix, the client id will be appended.
+const rpcReplyPrefix = "rpc/reply/"
+
+// Publish request on topic and wait the reply until context done, the
+// reply data decoded into resp when resp not nil, the requests and replies
+// always published without retain flag.
+//
+// # USAGE:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//
+//	ack := &CommandAck{}
+//	if err := mqtt.Singleton().Call(ctx, "cmd/"+devid, &Command{Action: "reboot"}, ack); err != nil {
+//		return err // the *mqtt.RPCError returned when device replied error
+//	}
+func (stub *MqttStub) Call(ctx context.Context, topic string, req, resp any) error {
+	replyto, err := stub.ensureReplyTopic()
+	if err != nil {
+		return err
+	}
+
+	env := &Envelope{ID: secure.NewSUID(), ReplyTo: replyto}
+	if deadline, ok := ctx.Deadline(); ok {
+		env.Deadline = deadline.UnixMilli()
+	}
+	if req != nil {
+		if env.Data, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+
+	reply := make(chan *Envelope, 1)
+	stub.rpcLock.Lock()
+	stub.calls[env.ID] = reply
+	stub.rpcLock.Unlock()
+
+	defer func() {
+		stub.rpcLock.Lock()
+		delete(stub.calls, env.ID)
+		stub.rpcLock.Unlock()
+	}()
+
+	// Never retain requests, or they will be replayed to new subscribers.
+	if err := stub.PublishOptions(topic, env, false); err != nil {
+		return err
+	}
+
+	select {
+	case rep := <-reply:
+		if rep.Error != "" {
+			return &RPCError{Message: rep.Error}
+		} else if resp != nil && len(rep.Data) > 0 {
+			return json.Unmarshal(rep.Data, resp)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe topic to handle rpc requests and publish replies, the handler
+// run in goroutine, and the panic will be recovered as error reply.
+//
+// # USAGE:
+//
+//	stub := mqtt.Singleton()
+//	stub.HandleRPC("cmd/"+devid, mqtt.RPCFunc(func(topic string, cmd *Command) (any, error) {
+//		return &CommandAck{Done: true}, execute(cmd)
+//	}))
+//
+// # WARNING:
+//   - The expired requests will be dropped without reply.
+func (stub *MqttStub) HandleRPC(topic string, fn RPCHandler, Qos ...byte) error {
+	if fn == nil {
+		return invar.ErrInvalidParams
+	}
+
+	return stub.Subscribe(topic, func(client mq.Client, msg mq.Message) {
+		env := &Envelope{}
+		if err := json.Unmarshal(msg.Payload(), env); err != nil || env.ID == "" || env.ReplyTo == "" {
+			mqxlog.W("Drop invalid rpc request of topic:", msg.Topic())
+			return
+		} else if env.Deadline > 0 && time.Now().UnixMilli() > env.Deadline {
+			mqxlog.W("Drop expired rpc request:", env.ID, "of topic:", msg.Topic())
+			return
+		}
+
+		// Not block the message receiving when publish reply
+		go stub.serveRPC(msg.Topic(), env, fn)
+	}, Qos...)
+}
+
+// Adapt typed function as rpc handler, the request data decoded as T.
+func RPCFunc[T any](fn func(topic string, req *T) (any, error)) RPCHandler {
+	return func(topic string, data json.RawMessage) (any, error) {
+		req := new(T)
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, req); err != nil {
+				return nil, err
+			}
+		}
+		return fn(topic, req)
+	}
+}
+
+// Return the error message replied by rpc handler.
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Subscribe the reply topic of rpc calls once, the topic will be restored
+// after reconnected.
+//
+// # WARNING:
+//   - The subscribing not hold rpc lock to keep replies delivering, and the
+//     concurrent calls wait for the same subscribing done.
+func (stub *MqttStub) ensureReplyTopic() (string, error) {
+	for {
+		stub.rpcLock.Lock()
+		if topic := stub.replyTopic; topic != "" {
+			stub.rpcLock.Unlock()
+			return topic, nil
+		} else if stub.Client == nil {
+			stub.rpcLock.Unlock()
+			return "", invar.ErrInvalidClient
+		} else if replying := stub.replying; replying != nil {
+			stub.rpcLock.Unlock()
+			<-replying // check again after subscribing done.
+			continue
+		}
+		replying := make(chan struct{})
+		stub.replying = replying
+		stub.rpcLock.Unlock()
+
+		topic, err := stub.subscribeReply()
+		stub.rpcLock.Lock()
+		if err == nil {
+			stub.replyTopic = topic
+			if stub.calls == nil {
+				stub.calls = make(map[string]chan *Envelope)
+			}
+		}
+		stub.replying = nil
+		close(replying)
+		stub.rpcLock.Unlock()
+		return topic, err
+	}
+}
+
+// Subscribe the reply topic named by client id to receive rpc replies.
+func (stub *MqttStub) subscribeReply() (string, error) {
+	opts := stub.Client.OptionsReader()
+	clientid := opts.ClientID()
+	if clientid == "" {
+		clientid = secure.NewCode()
+	}
+
+	topic := rpcReplyPrefix + clientid
+	if err := stub.Subscribe(topic, stub.onReply); err != nil {
+		return "", err
+	}
+	return topic, nil
+}
+
+// Deliver the reply to waiting call, the reply of timeout call dropped.
+func (stub *MqttStub) onReply(client mq.Client, msg mq.Message) {
+	env := &Envelope{}
+	if err := json.Unmarshal(msg.Payload(), env); err != nil {
+		mqxlog.W("Drop invalid rpc reply, err:", err)
+		return
+	}
+
+	stub.rpcLock.Lock()
+	reply, ok := stub.calls[env.ID]
+	stub.rpcLock.Unlock()
+	if !ok {
+		mqxlog.W("Drop rpc reply:", env.ID, "without waiting call")
+		return
+	}
+
+	select {
+	case reply <- env:
+	default: // duplicated reply
+	}
+}
+
+// Call rpc handler and publish reply to the reply topic of request.
+func (stub *MqttStub) serveRPC(topic string, req *Envelope, fn RPCHandler) {
+	rep := &Envelope{ID: req.ID}
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				mqxlog.E("Handle rpc topic:", topic, "panic:", p)
+				rep.Error = fmt.Sprintf("panic: %v", p)
+			}
+		}()
+
+		data, err := fn(topic, req.Data)
+		if err != nil {
+			rep.Error = err.Error()
+		} else if data != nil {
+			if rep.Data, err = json.Marshal(data); err != nil {
+				rep.Error = err.Error()
+			}
+		}
+	}()
+
+	if err := stub.PublishOptions(req.ReplyTo, rep, false); err != nil {
+		mqxlog.E("Reply rpc request:", req.ID, "err:", err)
+	}
+}
@@ -11,7 +11,9 @@
 package enc
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,3 +41,55 @@ func TestTokener(t *testing.T) {
 	}
 	fmt.Println("Keyword:", keyword, "- Claims:", claims)
 }
+
+func TestKeyRing(t *testing.T) {
+	master, _ := NewMasterKey([]byte(secure.NewAESKey()))
+	ring := NewKeyRing("acc", master)
+	if _, err := ring.Encrypt([]byte("13800000000")); err == nil {
+		t.Fatal("Encrypted without data key!")
+	}
+
+	if _, err := ring.Rotate(); err == nil {
+		t.Fatal("Rotated before load stored keys!")
+	}
+
+	ring.Load()
+	ring.Rotate()
+	old, _ := ring.Encrypt([]byte("13800000000"))
+	ring.Rotate()
+	if !ring.NeedsReencrypt(old) {
+		t.Fatal("Expect re-encrypt old ciphertext")
+	}
+
+	// reload wrapped keys by new key ring
+	reload := NewKeyRing("acc", master)
+	if err := reload.Load(ring.Export()...); err != nil || reload.Primary() != 2 {
+		t.Fatal("Load wrapped keys, err:", err)
+	}
+
+	reencrypted, ok, err := reload.Reencrypt(old)
+	if err != nil || !ok {
+		t.Fatal("Re-encrypt old ciphertext, err:", err)
+	} else if plaintext, err := reload.Decrypt(reencrypted); err != nil || string(plaintext) != "13800000000" {
+		t.Fatal("Decrypt re-encrypted ciphertext, err:", err)
+	}
+
+	// tamper the ciphertext header
+	if _, err := reload.Decrypt(strings.Replace(reencrypted, ":v2:", ":v1:", 1)); err == nil {
+		t.Fatal("Decrypted tampered ciphertext!")
+	}
+
+	// migrate legacy encoder values
+	legacy := NewEncoder(secure.NewAESKey())
+	reload.SetLegacy(legacy)
+	values := []*EncValue{{1, legacy.Encrypt("legacy")}, {2, old}, {3, reencrypted}}
+	count, err := reload.ReencryptAll(context.Background(), 10,
+		func(ctx context.Context, limit int) ([]*EncValue, error) { return values, nil },
+		func(ctx context.Context, changed []*EncValue) error { return nil })
+	if err != nil || count != 2 {
+		t.Fatal("Re-encrypt all, count:", count, "err:", err)
+	} else if plaintext, _ := reload.Decrypt(values[0].Value); string(plaintext) != "legacy" {
+		t.Fatal("Migrated legacy value unmatched!")
+	}
+	fmt.Println("Re-encrypted:", values[0].Value)
+}
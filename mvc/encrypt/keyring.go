@@ -0,0 +1,425 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package enc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/secure"
+)
+
+// Master key to wrap and unwrap data encryption keys, implement it by
+// cloud KMS client to keep master key out of servers.
+type MasterKey interface {
+	Wrap(dek []byte) ([]byte, error)       // Encrypt data encryption key
+	Unwrap(wrapped []byte) ([]byte, error) // Decrypt wrapped data encryption key
+}
+
+// Wrapped data encryption key with version, store it in database or
+// config files, the plain key only kept in memory.
+type WrappedKey struct {
+	Version int    `json:"version"` // Key version, increased by rotation
+	Wrapped string `json:"wrapped"` // Wrapped key as base64 string
+	Created int64  `json:"created"` // Created time in unix seconds
+}
+
+// Stored encrypted value for re-encryption.
+type EncValue struct {
+	ID    any    // Record id to save back, such as primary key
+	Value string // Stored ciphertext
+}
+
+// Fetch next batch of stored values which not encrypted by primary key,
+// use KeyRing.Prefix() to filter values, return empty to stop.
+type FetchFunc func(ctx context.Context, limit int) ([]*EncValue, error)
+
+// Save re-encrypted values.
+type SaveFunc func(ctx context.Context, values []*EncValue) error
+
+// Key ring of versioned data encryption keys wrapped by master key, it
+// encrypt datas by the primary (latest) key with AES-256-GCM and random
+// nonce, and decrypt datas by any loaded key of ciphertext version.
+//
+// The ciphertext format as 'ring:v2:gcm:nonce:ciphertext', the prefix
+// header also authenticated as additional data.
+//
+// # USAGE:
+//
+//	master, _ := enc.MasterKeyFromEnv("XCORE_MASTER_KEY")
+//	ring := enc.NewKeyRing("acc", master)
+//	ring.Load(storedKeys...)           // load wrapped keys from database
+//	if len(storedKeys) == 0 {
+//		wk, _ := ring.Rotate()         // create first key and save it
+//	}
+//	ciphertext, _ := ring.Encrypt([]byte("13800000000"))
+//	plaintext, _ := ring.Decrypt(ciphertext)
+type KeyRing struct {
+	mutex   sync.RWMutex
+	id      string
+	master  MasterKey
+	keys    map[int][]byte // Plain data encryption keys by version
+	wrapped map[int]*WrappedKey
+	primary int
+	loaded  bool     // Wrapped keys loaded from store, even empty
+	legacy  *Encoder // Legacy encoder to decrypt values without prefix
+}
+
+// Local master key of AES-256-GCM.
+type localMaster []byte
+
+// Data encryption key length in bytes for AES-256.
+const dekBytes = 32
+
+// AEAD mode of ciphertext header.
+const aeadMode = "gcm"
+
+// Create local master key by 16, 24 or 32 bytes AES key.
+func NewMasterKey(key []byte) (MasterKey, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		return localMaster(key), nil
+	}
+	return nil, invar.ErrKeyLenSixteen
+}
+
+// Load local master key from file, the file content is base64 key string.
+func MasterKeyFromFile(fp string) (MasterKey, error) {
+	content, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	return parseMasterKey(string(content))
+}
+
+// Load local master key from environment variable of base64 key string.
+func MasterKeyFromEnv(name string) (MasterKey, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, invar.ErrInvalidConfigs
+	}
+	return parseMasterKey(value)
+}
+
+// Wrap data encryption key by AES-GCM, output as nonce + ciphertext.
+func (m localMaster) Wrap(dek []byte) ([]byte, error) {
+	ciphertext, nonce, err := secure.GCMEncrypt(m, dek)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := secure.Base64ToByte(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nonce), buf...), nil
+}
+
+// Unwrap data encryption key wrapped by Wrap().
+func (m localMaster) Unwrap(wrapped []byte) ([]byte, error) {
+	const nonceSize = 12
+	if len(wrapped) <= nonceSize {
+		return nil, invar.ErrInvalidData
+	}
+
+	nonce, ciphertext := wrapped[:nonceSize], secure.ByteToBase64(wrapped[nonceSize:])
+	dek, err := secure.GCMDecrypt(m, ciphertext, string(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(dek), nil
+}
+
+// Create key ring by ring id and master key, the ring id output in
+// ciphertext header and can not contain ':' char.
+func NewKeyRing(id string, master MasterKey) *KeyRing {
+	return &KeyRing{
+		id: strings.ReplaceAll(id, ":", "_"), master: master,
+		keys: make(map[int][]byte), wrapped: make(map[int]*WrappedKey),
+	}
+}
+
+// Set legacy encoder to decrypt the values encrypted by Encoder, so the
+// legacy values can be migrated by Reencrypt() or ReencryptAll().
+func (k *KeyRing) SetLegacy(legacy *Encoder) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.legacy = legacy
+}
+
+// Load wrapped keys from store, the latest version used as primary key,
+// it must be called before Rotate() even no key stored.
+func (k *KeyRing) Load(keys ...*WrappedKey) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for _, wk := range keys {
+		wrapped, err := base64.StdEncoding.DecodeString(wk.Wrapped)
+		if err != nil {
+			return invar.ErrInvalidData
+		}
+
+		dek, err := k.master.Unwrap(wrapped)
+		if err != nil {
+			return err
+		}
+
+		k.keys[wk.Version], k.wrapped[wk.Version] = dek, wk
+		if wk.Version > k.primary {
+			k.primary = wk.Version
+		}
+	}
+	k.loaded = true
+	return nil
+}
+
+// Create a new data encryption key as primary key, and return the wrapped
+// key to save, the old keys still used to decrypt old ciphertexts.
+//
+// It return invar.ErrNotInited when stored keys not loaded, to avoid the
+// new key version collide with stored keys.
+func (k *KeyRing) Rotate() (*WrappedKey, error) {
+	k.mutex.RLock()
+	loaded := k.loaded
+	k.mutex.RUnlock()
+	if !loaded {
+		return nil, invar.ErrNotInited
+	}
+
+	dek := make([]byte, dekBytes)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.master.Wrap(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.primary++
+	wk := &WrappedKey{
+		Version: k.primary, Wrapped: base64.StdEncoding.EncodeToString(wrapped), Created: time.Now().Unix(),
+	}
+	k.keys[wk.Version], k.wrapped[wk.Version] = dek, wk
+	return wk, nil
+}
+
+// Remove the old key after all ciphertexts re-encrypted, the primary key
+// can not be retired.
+func (k *KeyRing) Retire(version int) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if version == k.primary {
+		return invar.ErrInvalidState
+	}
+	delete(k.keys, version)
+	delete(k.wrapped, version)
+	return nil
+}
+
+// Return all wrapped keys to save.
+func (k *KeyRing) Export() []*WrappedKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	keys := make([]*WrappedKey, 0, len(k.wrapped))
+	for version := 1; version <= k.primary; version++ {
+		if wk, ok := k.wrapped[version]; ok {
+			keys = append(keys, wk)
+		}
+	}
+	return keys
+}
+
+// Return the primary key version.
+func (k *KeyRing) Primary() int {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.primary
+}
+
+// Return the ciphertext prefix of key version, such as 'acc:v2:gcm:'.
+func (k *KeyRing) Prefix(version int) string {
+	return k.header(version) + ":"
+}
+
+// Encrypt plaintext by primary key, and return ciphertext with header.
+func (k *KeyRing) Encrypt(plaintext []byte) (string, error) {
+	k.mutex.RLock()
+	version, dek := k.primary, k.keys[k.primary]
+	k.mutex.RUnlock()
+
+	if dek == nil {
+		return "", invar.ErrNotInited
+	}
+
+	header := k.header(version)
+	ciphertext, nonce, err := secure.GCMEncrypt(dek, plaintext, []byte(header))
+	if err != nil {
+		return "", err
+	}
+	return header + ":" + secure.ByteToBase64([]byte(nonce)) + ":" + ciphertext, nil
+}
+
+// Decrypt ciphertext by the key of header version, or by legacy encoder
+// when ciphertext not prefixed and legacy encoder set.
+func (k *KeyRing) Decrypt(ciphertext string) ([]byte, error) {
+	version, nonce, data, err := k.parse(ciphertext)
+	if err == invar.ErrUnsupportFormat {
+		plaintext, err := k.decryptLegacy(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(plaintext), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	k.mutex.RLock()
+	dek := k.keys[version]
+	k.mutex.RUnlock()
+	if dek == nil {
+		return nil, invar.ErrNotFound
+	}
+
+	plaintext, err := secure.GCMDecrypt(dek, data, nonce, []byte(k.header(version)))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
+
+// Check whether the ciphertext not encrypted by primary key.
+func (k *KeyRing) NeedsReencrypt(ciphertext string) bool {
+	return !strings.HasPrefix(ciphertext, k.Prefix(k.Primary()))
+}
+
+// Re-encrypt ciphertext by primary key, it return the original ciphertext
+// and false when it already encrypted by primary key.
+func (k *KeyRing) Reencrypt(ciphertext string) (string, bool, error) {
+	if !k.NeedsReencrypt(ciphertext) {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	reencrypted, err := k.Encrypt(plaintext)
+	return reencrypted, err == nil, err
+}
+
+// Re-encrypt all stored values by batches until fetch returned empty, or
+// the context canceled, and return the re-encrypted count, call it in
+// goroutine to run in background.
+//
+//	go func() {
+//		count, err := ring.ReencryptAll(ctx, 100, fetchOldPhones, savePhones)
+//		logger.I("Re-encrypted phones:", count, "err:", err)
+//	}()
+func (k *KeyRing) ReencryptAll(ctx context.Context, batch int, fetch FetchFunc, save SaveFunc) (int, error) {
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		default:
+		}
+
+		values, err := fetch(ctx, batch)
+		if err != nil || len(values) == 0 {
+			return count, err
+		}
+
+		changed := []*EncValue{}
+		for _, value := range values {
+			reencrypted, ok, err := k.Reencrypt(value.Value)
+			if err != nil {
+				return count, err
+			} else if ok {
+				value.Value, changed = reencrypted, append(changed, value)
+			}
+		}
+
+		// stop when fetched values all encrypted by primary key
+		if len(changed) == 0 {
+			return count, nil
+		} else if err := save(ctx, changed); err != nil {
+			return count, err
+		}
+		count += len(changed)
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Parse master key from base64 string.
+func parseMasterKey(value string) (MasterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, invar.ErrInvalidConfigs
+	}
+	return NewMasterKey(key)
+}
+
+// Return ciphertext header of key version, such as 'acc:v2:gcm'.
+func (k *KeyRing) header(version int) string {
+	return k.id + ":v" + strconv.Itoa(version) + ":" + aeadMode
+}
+
+// Parse ciphertext as key version, nonce and base64 datas.
+func (k *KeyRing) parse(ciphertext string) (int, string, string, error) {
+	parts := strings.Split(ciphertext, ":")
+	if len(parts) != 5 || parts[0] != k.id || parts[2] != aeadMode || !strings.HasPrefix(parts[1], "v") {
+		return 0, "", "", invar.ErrUnsupportFormat
+	}
+
+	version, err := strconv.Atoi(parts[1][1:])
+	if err != nil {
+		return 0, "", "", invar.ErrUnsupportFormat
+	}
+
+	nonce, err := secure.Base64ToByte(parts[3])
+	if err != nil {
+		return 0, "", "", invar.ErrUnsupportFormat
+	}
+	return version, string(nonce), parts[4], nil
+}
+
+// Decrypt legacy ciphertext by legacy encoder, the empty ciphertext return
+// empty plaintext, and return invar.ErrUnsupportFormat when legacy unset.
+func (k *KeyRing) decryptLegacy(ciphertext string) (string, error) {
+	k.mutex.RLock()
+	legacy := k.legacy
+	k.mutex.RUnlock()
+
+	if legacy == nil {
+		return "", invar.ErrUnsupportFormat
+	} else if ciphertext == "" {
+		return "", nil
+	}
+	return secure.AESDecrypt([]byte(*legacy), ciphertext)
+}
@@ -27,9 +27,11 @@ import (
 type InsertBuilder struct {
 	BaseBuilder
 	rows []pd.KValues // Target row records to insert.
+	encs bool         // Flag whether rows values encrypted.
 }
 
 var _ pd.Builder = (*InsertBuilder)(nil)
+var _ pd.Encryptable = (*InsertBuilder)(nil)
 
 // Create a InsertBuilder instance to build a query string.
 func NewInsert(table string, provider ...pd.ProviderUtils) *InsertBuilder {
@@ -92,16 +94,36 @@ func (b *InsertBuilder) InsertUncheck() error { return b.provider.InsertUncheck(
 //	// => ?,?,?,?,?
 //	// => (16,true,"ZhangSan",176.8,NULL),(15,false,"LiXu",168.5,"prikey")
 func (b *InsertBuilder) Values(row ...pd.KValues) *InsertBuilder {
-	b.rows = row
+	b.rows, b.encs = row, false
 	return b
 }
 
 // Reset builder datas for next prepare and build.
 func (b *InsertBuilder) Reset() *InsertBuilder {
 	clear(b.rows)
+	b.encs = false
 	return b
 }
 
+// Encrypt the values of encrypted columns for all rows, it called by
+// TableProvider when set pd.FieldCipher, and only encrypt once.
+func (b *InsertBuilder) EncryptValues(c *pd.FieldCipher) error {
+	if b.encs {
+		return nil
+	}
+
+	rows := make([]pd.KValues, 0, len(b.rows))
+	for _, row := range b.rows {
+		values, err := c.EncryptValues(row)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, values)
+	}
+	b.rows, b.encs = rows, true
+	return nil
+}
+
 // Return rows count which insert to table later.
 func (b *InsertBuilder) ValRows() int {
 	return len(b.rows)
@@ -49,6 +49,9 @@ func NewQuery(table string, provider ...pd.ProviderUtils) *QueryBuilder {
 // Return out params, maybe empty when called before Build().
 func (b *QueryBuilder) GetOuts() []any { return b.outs }
 
+// Return the target output fields name.
+func (b *QueryBuilder) GetTags() []string { return b.tags }
+
 /* ------------------------------------------------------------------- */
 /* For Provider Query Utils                                            */
 /* ------------------------------------------------------------------- */
@@ -267,5 +267,50 @@ func TestItemScaner(t *testing.T) {
 	}
 }
 
+/* ------------------------------------------------------------------- */
+/* For provider.FieldCipher Tests                                      */
+/* ------------------------------------------------------------------- */
+
+func TestFieldCipher(t *testing.T) {
+	cipher := pd.NewFieldCipher([]byte("0123456789abcdef0123456789abcdef"), []byte("blind-index-key")).
+		Encrypted("phone", "phone_idx").Encrypted("idcard")
+
+	builder := NewInsert("account").Values(pd.KValues{"uid": "123", "phone": "13800000000", "idcard": "11010519491231002x"})
+	if err := builder.EncryptValues(cipher); err != nil {
+		t.Fatal("Encrypt insert values, err:", err)
+	}
+
+	query, args := builder.Build()
+	if !strings.Contains(query, "phone_idx") || len(args) != 4 {
+		t.Fatal("Invalid encrypted insert:", query, args)
+	}
+
+	outs := make([]any, 0, len(args))
+	tags := strings.Split(strings.TrimSuffix(strings.Split(query, "(")[1], ") VALUES "), ", ")
+	for i, tag := range tags {
+		value := args[i].(string)
+		switch tag {
+		case "phone", "idcard":
+			if !strings.HasPrefix(value, "enc1:") {
+				t.Fatal("Column", tag, "not encrypted:", value)
+			}
+		case "phone_idx":
+			if where := cipher.Where("a.phone", " 13800000000 "); where["a.phone_idx=?"] != value {
+				t.Fatal("Unmatched blind index:", where)
+			}
+		}
+		outs = append(outs, &value)
+	}
+
+	if err := cipher.DecryptOuts(tags, outs); err != nil {
+		t.Fatal("Decrypt outs, err:", err)
+	}
+	for i, tag := range tags {
+		if tag == "phone" && *(outs[i].(*string)) != "13800000000" {
+			t.Fatal("Decrypted phone unmatched:", *(outs[i].(*string)))
+		}
+	}
+}
+
 // TODO
 // ...
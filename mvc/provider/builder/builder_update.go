@@ -31,9 +31,11 @@ type UpdateBuilder struct {
 	sep    string     // Where conditions connector, one of 'AND', 'OR', ' ', default ''.
 	ins    string     // Where in conditions.
 	like   string     // Like conditions string.
+	encs   bool       // Flag whether values encrypted.
 }
 
 var _ pd.Builder = (*UpdateBuilder)(nil)
+var _ pd.Encryptable = (*UpdateBuilder)(nil)
 
 // Create a UpdateBuilder instance to build a query string.
 func NewUpdate(table string, provider ...pd.ProviderUtils) *UpdateBuilder {
@@ -79,10 +81,25 @@ func (b *UpdateBuilder) ExecResult() (int64, error) { return b.provider.ExecResu
 //	// => SET Age=?, Male=?, Name=?, Height=?, Secure=NULL
 //	// => values: []any{16, true, "ZhangSan", 176.8, nil}
 func (b *UpdateBuilder) Values(row pd.KValues) *UpdateBuilder {
-	b.values = row
+	b.values, b.encs = row, false
 	return b
 }
 
+// Encrypt the values of encrypted columns, it called by TableProvider
+// when set pd.FieldCipher, and only encrypt once.
+func (b *UpdateBuilder) EncryptValues(c *pd.FieldCipher) error {
+	if b.encs {
+		return nil
+	}
+
+	values, err := c.EncryptValues(b.values)
+	if err != nil {
+		return err
+	}
+	b.values, b.encs = values, true
+	return nil
+}
+
 // Specify the where conditions and args for query.
 //
 //	where = pd.Wheres{
@@ -135,6 +152,7 @@ func (b *UpdateBuilder) Reset() *UpdateBuilder {
 	clear(b.values)
 	clear(b.wheres)
 	b.sep, b.ins, b.like = "", "", ""
+	b.encs = false
 	return b
 }
 
@@ -0,0 +1,198 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package pd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/secure"
+)
+
+// A interface implement by InsertBuilder and UpdateBuilder to encrypt
+// the values of encrypted columns before build sql string.
+type Encryptable interface {
+	EncryptValues(c *FieldCipher) error
+}
+
+// Column values cipher to encrypt marked columns by AES-GCM on write, and
+// decrypt them on read, it also output a deterministic blind index (HMAC)
+// into the index column for equality lookups.
+//
+// # USAGE:
+//
+//	cipher := pd.NewFieldCipher(aeskey, hmackey).
+//		Encrypted("phone", "phone_idx"). // encrypt with blind index column.
+//		Encrypted("idcard")              // only encrypt.
+//	h := &Accounts{provider.NewTableProvider(mysql.Select(),
+//		provider.WithTable("account"), provider.WithCipher(cipher))}
+//
+//	// phone, phone_idx, idcard encrypted automatically.
+//	h.Inserter().Values(pd.KValues{"uid": uid, "phone": phone, "idcard": idcard}).Exec()
+//
+//	// query by blind index and decrypt phone automatically.
+//	h.Querier().Tags("uid", "phone").Wheres(cipher.Where("phone", phone)).OneDone()
+//
+// # WARNING:
+//   - Only string values encrypted, and the encrypted values not support LIKE or range query.
+//   - The raw BaseProvider and ScanCallback queries not decrypt values.
+type FieldCipher struct {
+	key     []byte            // AES key of 16, 24, 32 bytes.
+	idxkey  []byte            // HMAC key for blind index.
+	columns map[string]string // Encrypted columns mapping to blind index columns.
+}
+
+// Ciphertext prefix to distinguish encrypted values from plaintext.
+const cipherPrefix = "enc1:"
+
+// Create a field cipher with AES key and HMAC key of blind index.
+func NewFieldCipher(key, idxkey []byte) *FieldCipher {
+	return &FieldCipher{key: key, idxkey: idxkey, columns: make(map[string]string)}
+}
+
+// Mark column as encrypted, and the optional blind index column.
+func (c *FieldCipher) Encrypted(column string, index ...string) *FieldCipher {
+	idxcol := ""
+	if len(index) > 0 {
+		idxcol = index[0]
+	}
+	c.columns[column] = idxcol
+	return c
+}
+
+// Check whether the column encrypted, the table alias prefix ignored.
+func (c *FieldCipher) IsEncrypted(column string) bool {
+	_, ok := c.columns[trimAlias(column)]
+	return ok
+}
+
+// Encrypt plaintext of column, the column name used as additional data.
+func (c *FieldCipher) Encrypt(column, plaintext string) (string, error) {
+	ciphertext, nonce, err := secure.GCMEncrypt(c.key, []byte(plaintext), []byte(trimAlias(column)))
+	if err != nil {
+		return "", err
+	}
+	return cipherPrefix + secure.ByteToBase64([]byte(nonce)) + ":" + ciphertext, nil
+}
+
+// Decrypt ciphertext of column, the plaintext value without prefix returned
+// directly, so the exist plaintext datas can be encrypted gradually.
+func (c *FieldCipher) Decrypt(column, ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, cipherPrefix) {
+		return ciphertext, nil
+	}
+
+	nonceb64, data, ok := strings.Cut(strings.TrimPrefix(ciphertext, cipherPrefix), ":")
+	if !ok {
+		return "", invar.ErrUnsupportFormat
+	}
+
+	nonce, err := secure.Base64ToByte(nonceb64)
+	if err != nil {
+		return "", invar.ErrUnsupportFormat
+	}
+	return secure.GCMDecrypt(c.key, data, string(nonce), []byte(trimAlias(column)))
+}
+
+// Return the blind index of column plaintext as HMAC-SHA256 hex string,
+// the plaintext trimmed spaces and uppercased before hash.
+func (c *FieldCipher) BlindIndex(column, plaintext string) string {
+	mac := hmac.New(sha256.New, c.idxkey)
+	mac.Write([]byte(trimAlias(column) + ":" + strings.ToUpper(strings.TrimSpace(plaintext))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Return the where condition of blind index for equality lookup, it return
+// empty wheres when column not set blind index.
+//
+//	cipher.Where("phone", "13800000000") // => pd.Wheres{"phone_idx=?": "9f86d0..."}
+func (c *FieldCipher) Where(column, plaintext string) Wheres {
+	if idxcol := c.columns[trimAlias(column)]; idxcol != "" {
+		if idx := strings.LastIndex(column, "."); idx >= 0 {
+			idxcol = column[:idx+1] + idxcol // keep table alias.
+		}
+		return Wheres{idxcol + "=?": c.BlindIndex(column, plaintext)}
+	}
+	return Wheres{}
+}
+
+// Return a copy of values with encrypted columns values and blind indexs.
+func (c *FieldCipher) EncryptValues(values KValues) (KValues, error) {
+	outs := make(KValues, len(values))
+	for key, value := range values {
+		outs[key] = value
+	}
+
+	for key, value := range values {
+		idxcol, ok := c.columns[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		plaintext, ok := value.(string)
+		if !ok {
+			return nil, invar.ErrInvalidData
+		} else if plaintext == "" {
+			continue
+		}
+
+		ciphertext, err := c.Encrypt(key, plaintext)
+		if err != nil {
+			return nil, err
+		}
+
+		outs[key] = ciphertext
+		if idxcol != "" {
+			outs[idxcol] = c.BlindIndex(key, plaintext)
+		}
+	}
+	return outs, nil
+}
+
+// Decrypt the scaned outs of encrypted tags, the outs must be *string or
+// *sql.NullString of encrypted columns.
+func (c *FieldCipher) DecryptOuts(tags []string, outs []any) error {
+	for i, tag := range tags {
+		if i >= len(outs) || !c.IsEncrypted(tag) {
+			continue
+		}
+
+		switch out := outs[i].(type) {
+		case *string:
+			plaintext, err := c.Decrypt(tag, *out)
+			if err != nil {
+				return err
+			}
+			*out = plaintext
+		case *sql.NullString:
+			if out.Valid {
+				plaintext, err := c.Decrypt(tag, out.String)
+				if err != nil {
+					return err
+				}
+				out.String = plaintext
+			}
+		}
+	}
+	return nil
+}
+
+// Return column name without table alias, such as 'a.phone' to 'phone'.
+func trimAlias(column string) string {
+	if idx := strings.LastIndex(column, "."); idx >= 0 {
+		return column[idx+1:]
+	}
+	return column
+}
@@ -38,8 +38,9 @@ import (
 // to create TableProvider with connected mysql, mssql, sqlite database client.
 type TableProvider struct {
 	BaseProvider
-	table string // Table name.
-	debug bool   // Debug flag for print SQL actions, default false.
+	table  string          // Table name.
+	debug  bool            // Debug flag for print SQL actions, default false.
+	cipher *pd.FieldCipher // Encrypted columns cipher, optional.
 }
 
 var _ pd.Provider = (*TableProvider)(nil)
@@ -69,6 +70,15 @@ func WithTable(table string) Option {
 	return func(provider *TableProvider) { provider.table = table }
 }
 
+// Specify the cipher to encrypt the values of insert and update builders,
+// and decrypt the query results of Array(), Column() and OneDone().
+//
+//	cipher := pd.NewFieldCipher(aeskey, hmackey).Encrypted("phone", "phone_idx")
+//	table := provider.NewTableProvider(mysql.Select(), provider.WithTable("account"), provider.WithCipher(cipher))
+func WithCipher(cipher *pd.FieldCipher) Option {
+	return func(provider *TableProvider) { provider.cipher = cipher }
+}
+
 /* ------------------------------------------------------------------- */
 /* Create and Return Builder Instance FOR QUID Actions                 */
 /* ------------------------------------------------------------------- */
@@ -183,6 +193,19 @@ func (p *TableProvider) OneScan(b pd.Builder, cb pd.ScanCallback) error {
 func (p *TableProvider) OneDone(b pd.Builder, done ...pd.DoneCallback) error {
 	if qb, ok := b.(*builder.QueryBuilder); ok {
 		query, args := qb.Build(p.debug)
+		if p.cipher != nil {
+			if err := p.BaseProvider.OneDone(query, qb.GetOuts(), nil, args...); err != nil {
+				return err
+			} else if err := p.cipher.DecryptOuts(qb.GetTags(), qb.GetOuts()); err != nil {
+				return err
+			}
+
+			if cb := utils.Variable(done, nil); cb != nil {
+				cb()
+			}
+			return nil
+		}
+
 		if cb := utils.Variable(done, nil); cb != nil {
 			return p.BaseProvider.OneDone(query, qb.GetOuts(), cb, args...)
 		}
@@ -214,10 +237,15 @@ func (p *TableProvider) Query(b pd.Builder, cb pd.ScanCallback) error {
 //	}, /* func(iv *MyAcc) {} */) // or append parser function.
 //	h.Querier().Tags("name").Wheres(pd.Wheres{"role=?": "admin"}).Array(creator)
 func (p *TableProvider) Array(b pd.Builder, creator pd.Creator) error {
+	tags := p.cipherTags(b)
 	return p.Query(b, func(rows *sql.Rows) error {
 		item, outs := creator.CreateItem() // item is *T type, outs all & pointers!
 		if err := rows.Scan(outs...); err != nil {
 			return err
+		} else if len(tags) > 0 {
+			if err := p.cipher.DecryptOuts(tags, outs); err != nil {
+				return err
+			}
 		}
 		return creator.AppendItem(item)
 	})
@@ -230,10 +258,15 @@ func (p *TableProvider) Array(b pd.Builder, creator pd.Creator) error {
 //	scaner := pd.NewScaner(&names/* , func(iv *string) {} */)
 //	h.Querier().Tags("name").Wheres(pd.Wheres{"role=?": "admin"}).Column(scaner)
 func (p *TableProvider) Column(b pd.Builder, scaner pd.Scaner) error {
+	tags := p.cipherTags(b)
 	return p.Query(b, func(rows *sql.Rows) error {
 		out := scaner.CreateItem() // out is *T type!
 		if err := rows.Scan(out); err != nil {
 			return err
+		} else if len(tags) > 0 {
+			if err := p.cipher.DecryptOuts(tags, []any{out}); err != nil {
+				return err
+			}
 		}
 		return scaner.AppendItem(out)
 	})
@@ -244,6 +277,9 @@ func (p *TableProvider) Column(b pd.Builder, scaner pd.Scaner) error {
 //
 // Use BaseProvider.Exec() method to direct execute query string.
 func (p *TableProvider) Exec(b pd.Builder) error {
+	if err := p.encrypt(b); err != nil {
+		return err
+	}
 	query, args := b.Build(p.debug)
 	return p.BaseProvider.Exec(query, args...)
 }
@@ -253,6 +289,9 @@ func (p *TableProvider) Exec(b pd.Builder) error {
 //
 // Use BaseProvider.Exec() method to direct execute query string.
 func (p *TableProvider) ExecResult(b pd.Builder) (int64, error) {
+	if err := p.encrypt(b); err != nil {
+		return 0, err
+	}
 	query, args := b.Build(p.debug)
 	return p.BaseProvider.ExecResult(query, args...)
 }
@@ -263,6 +302,9 @@ func (p *TableProvider) ExecResult(b pd.Builder) (int64, error) {
 // Use BaseProvider.Insert() method to direct execute query string.
 func (p *TableProvider) Insert(b pd.Builder) (int64, error) {
 	if ib, ok := b.(*builder.InsertBuilder); ok {
+		if err := p.encrypt(ib); err != nil {
+			return -1, err
+		}
 		query, args := b.Build(p.debug)
 		if cnt := ib.ValRows(); cnt <= 0 {
 			return -1, invar.ErrInvalidData
@@ -302,6 +344,9 @@ func (p *TableProvider) InsertUncheck(b pd.Builder) error {
 // Use BaseProvider.Update() method to direct execute query string.
 func (p *TableProvider) Update(b pd.Builder) error {
 	if ub, ok := b.(*builder.UpdateBuilder); ok {
+		if err := p.encrypt(ub); err != nil {
+			return err
+		}
 		query, args := ub.Build(p.debug)
 		return p.BaseProvider.Update(query, args...)
 	}
@@ -355,3 +400,27 @@ func (p *TableProvider) Trans(cbs ...pd.TranerCallback) error {
 	}
 	return nil
 }
+
+// Encrypt the values of insert and update builders when cipher set.
+func (p *TableProvider) encrypt(b pd.Builder) error {
+	if p.cipher != nil {
+		if eb, ok := b.(pd.Encryptable); ok {
+			return eb.EncryptValues(p.cipher)
+		}
+	}
+	return nil
+}
+
+// Return the query tags when cipher set and any tag encrypted.
+func (p *TableProvider) cipherTags(b pd.Builder) []string {
+	if p.cipher != nil {
+		if qb, ok := b.(*builder.QueryBuilder); ok {
+			for _, tag := range qb.GetTags() {
+				if p.cipher.IsEncrypted(tag) {
+					return qb.GetTags()
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -134,12 +134,12 @@ func (c *WingController) SilentJSON(state int, data ...any) {
 	c.responCheckState(newOptions(true, true), state, data...)
 }
 
-// Sends a ['json', 'jsonp', 'xml', 'yarm'] response to client on status check mode.
+// Sends a ['json', 'jsonp', 'xml', 'yaml', 'msgpack', 'cbor'] response to client on status check mode.
 func (c *WingController) ResponAsType(datatype string, state int, data ...any) {
 	c.responCheckState(newOptions(true, false).outType(datatype), state, data...)
 }
 
-// Sends a ['json', 'jsonp', 'xml', 'yarm'] response to client witchout status check.
+// Sends a ['json', 'jsonp', 'xml', 'yaml', 'msgpack', 'cbor'] response to client witchout status check.
 func (c *WingController) UncheckAsType(datatype string, state int, dataORerr ...any) {
 	c.responCheckState(newOptions(false, false).outType(datatype), state, dataORerr...)
 }
@@ -479,12 +479,22 @@ func (c *WingController) DoAfterUnmarshal(ps any, nextFunc NextFunc, opts ...Opt
 /* ------------------------------------------------------------------- */
 
 // Check respon state and print out log, the datatype must range in
-// ['json', 'jsonp', 'xml', 'yaml'], if out of range current controller
-// just return blank string to close http connection.
+// ['json', 'jsonp', 'xml', 'yaml', 'msgpack', 'cbor'], if out of range
+// current controller just return blank string to close http connection.
+//
+// The datatype negotiate by request Accept header when enabled by
+// EnableNegotiate or WithNegotiate() option and not fixed by WithDataType()
+// option, and the encoded body maybe compressed by gzip or brotli, see
+// CompressThreshold and EnableETag.
 //
 // The protect param set true by default, by can be change from input flags.
 func (c *WingController) responCheckState(opts *Options, state int, data ...any) {
-	dt := strings.ToUpper(opts.datatype)
+	datatype, negotiated := opts.datatype, opts.nego && !opts.typed
+	if accept := c.Ctx.Request.Header.Get("Accept"); negotiated && accept != "" {
+		datatype = negotiateDataType(accept, datatype)
+	}
+
+	dt := strings.ToUpper(datatype)
 	if state != invar.StatusOK {
 		/* ------------------------------------------------------------
 		 * Not response error message to frontend when protect is true!
@@ -509,20 +519,18 @@ func (c *WingController) responCheckState(opts *Options, state int, data ...any)
 		logger.I("["+dt+"] Respone OK >", ctl+"."+act)
 	}
 
-	c.Ctx.Output.Status = state
+	var out any
 	if len(data) > 0 && data[0] != nil {
-		c.Data[opts.datatype] = data[0]
+		out = data[0]
 	}
 
-	switch opts.datatype {
-	case "json":
-		c.ServeJSON()
+	switch datatype {
+	case "json", "xml", "yaml", "msgpack", "cbor":
+		c.serveEncoded(datatype, state, out, negotiated)
 	case "jsonp":
+		c.Ctx.Output.Status = state
+		c.Data[datatype] = out
 		c.ServeJSONP()
-	case "xml":
-		c.ServeXML()
-	case "yaml":
-		c.ServeYAML()
 	default:
 		// just return blank string to close http connection
 		logger.W("Unsupport response type:" + dt)
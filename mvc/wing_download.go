@@ -0,0 +1,183 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mvc
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+)
+
+// StreamFunc write generated content into writer, the written datas will
+// flush to client immediately.
+type StreamFunc func(w io.Writer) error
+
+/* ------------------------------------------------------------------- */
+/* For Download Utils                                                  */
+/* ------------------------------------------------------------------- */
+
+// Serve local file to client for download, it support HTTP Range requests
+// (include multipart byte ranges), and conditional requests by headers of
+// If-Modified-Since, If-None-Match, If-Range.
+//
+// The filename param use to set Content-Disposition header, or use the
+// local file name as default, set inline true to display on browser.
+//
+//	@Return 404: File not found or directory path.
+//
+// # USAGE:
+//
+//	// ensure invar.EnableMimeTypes() called to detect content type.
+//	func (c *FileController) Download() {
+//		c.ServeFile("./files/report.pdf", "月度报表.pdf")
+//	}
+func (c *WingController) ServeFile(fp string, filename string, inline ...bool) {
+	file, err := os.Open(fp)
+	if err != nil {
+		logger.E("Open download file:", fp, "err:", err)
+		c.E404Exception("File not found!")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		logger.E("Invalid download file:", fp)
+		c.E404Exception("File not found!")
+		return
+	}
+
+	if filename == "" {
+		filename = info.Name()
+	}
+	c.ServeReader(filename, info.ModTime(), file, inline...)
+}
+
+// Serve the content of seekable reader to client, it support HTTP Range
+// requests and conditional requests same as ServeFile().
+//
+// The modtime use for Last-Modified header and set zero time to ignore,
+// it will output a strong ETag created by modtime and content size when
+// ETag header not set before call this method, so the resumed downloads
+// with If-Range header can be responsed as partial content.
+//
+//	c.OutHeader("ETag", `"custom-etag"`) // optional set ETag.
+//	c.ServeReader("avatar.png", updated, bytes.NewReader(datas), true)
+func (c *WingController) ServeReader(filename string, modtime time.Time, content io.ReadSeeker, inline ...bool) {
+	w := c.Ctx.ResponseWriter
+	c.setDownloadHeaders(filename, len(inline) > 0 && inline[0])
+
+	if w.Header().Get("ETag") == "" && !modtime.IsZero() {
+		if size, err := content.Seek(0, io.SeekEnd); err == nil {
+			if _, err := content.Seek(0, io.SeekStart); err == nil {
+				etag := strconv.FormatInt(modtime.UnixNano(), 16) + "-" + strconv.FormatInt(size, 16)
+				w.Header().Set("ETag", "\""+etag+"\"")
+			}
+		}
+	}
+
+	ctl, act := c.GetControllerAndAction()
+	logger.I("Serve file:", filename, ">", ctl+"."+act)
+	http.ServeContent(w, c.Ctx.Request, filename, modtime, content)
+}
+
+// Serve generated content to client incrementally, such as CSV exports,
+// the datas flushed to client after every writing, and the response not
+// support Range requests.
+//
+//	func (c *ExportController) ExportCSV() {
+//		c.ServeStream("orders.csv", func(w io.Writer) error {
+//			cw := csv.NewWriter(w)
+//			for rows := range service.OrderPages() {
+//				if err := cw.WriteAll(rows); err != nil {
+//					return err
+//				}
+//			}
+//			return nil
+//		})
+//	}
+func (c *WingController) ServeStream(filename string, next StreamFunc, inline ...bool) {
+	w := c.Ctx.ResponseWriter
+	c.setDownloadHeaders(filename, len(inline) > 0 && inline[0])
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(invar.StatusOK)
+
+	ctl, act := c.GetControllerAndAction()
+	if err := next(&flushWriter{w: w}); err != nil {
+		// the status code already sent, just log out the error.
+		logger.E("Stream", filename, ">", ctl+"."+act, "err:", err)
+		return
+	}
+	logger.I("Streamed file:", filename, ">", ctl+"."+act)
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Writer to flush datas to client after every writing.
+type flushWriter struct {
+	w io.Writer
+}
+
+// Write datas and flush if the writer is http.Flusher.
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if flusher, ok := fw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// Set Content-Type by file suffix, and Content-Disposition with UTF-8 filename.
+func (c *WingController) setDownloadHeaders(filename string, inline bool) {
+	header := c.Ctx.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", *invar.GetContentType(filepath.Ext(filename)))
+	}
+	header.Set("Content-Disposition", contentDisposition(filename, inline))
+}
+
+// Return Content-Disposition value with ascii fallback and UTF-8 filename.
+//
+//	attachment; filename="____.pdf"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.pdf
+func contentDisposition(filename string, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+
+	if filename = filepath.Base(filename); filename == "" || filename == "." {
+		return disposition
+	}
+
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '%' {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	value := disposition + "; filename=\"" + fallback + "\""
+	if fallback != filename {
+		value += "; filename*=UTF-8''" + url.PathEscape(filename)
+	}
+	return value
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+)
+
+// Typed handler function to execute api action with parsed input params,
+// then return the typed result or error.
+type HandleFunc[In, Out any] func(ctx context.Context, in *In) (Out, error)
+
+// Typed handler function to execute api action with authed account secures
+// and parsed input params, then return the typed result or error.
+type AuthHandleFunc[In, Out any] func(ctx context.Context, a *WAuths, in *In) (Out, error)
+
+// Empty input params for typed handlers, it not bind any params from request.
+type NoParams struct{}
+
+// A interface implement by WingController and all extend controllers,
+// it only used for typed handlers.
+type WingHandler interface {
+	wing() *WingController
+}
+
+// A interface implement by WAuthController and WRoleController, it only
+// used for auth typed handlers.
+type AuthHandler interface {
+	WingHandler
+	authRequest(silent bool) *WAuths
+}
+
+// A interface implement by custom error to indicate response status.
+type StatusError interface {
+	error
+	Status() int
+}
+
+// Errors mapping to response status for typed handlers, the unmapped
+// errors will response 404 as server internal error.
+var errStatus = []struct {
+	err    invar.WingErr
+	status int
+}{
+	{invar.ErrInvalidParams, invar.E400ParseParams},
+	{invar.ErrInvalidData, invar.E400ParseParams},
+	{invar.ErrInvalidNum, invar.E400ParseParams},
+	{invar.ErrInvalidPhone, invar.E400ParseParams},
+	{invar.ErrInvalidEmail, invar.E400ParseParams},
+	{invar.ErrInvalidName, invar.E400ParseParams},
+	{invar.ErrInvaildTime, invar.E400ParseParams},
+	{invar.ErrUnsupportFormat, invar.E400ParseParams},
+	{invar.ErrInvalidToken, invar.E401Unauthorized},
+	{invar.ErrTokenExpired, invar.E401Unauthorized},
+	{invar.ErrInvalidAccount, invar.E401Unauthorized},
+	{invar.ErrInactiveAccount, invar.E401Unauthorized},
+	{invar.ErrAuthDenied, invar.E403PermissionDenied},
+	{invar.ErrInvalidRole, invar.E403PermissionDenied},
+	{invar.ErrNotFound, invar.E404Exception},
+	{invar.ErrNoneRowFound, invar.E404Exception},
+	{invar.ErrNotSupport, invar.E405FuncDisabled},
+	{invar.ErrDupRegister, invar.E409Duplicate},
+	{invar.ErrDupLogin, invar.E409Duplicate},
+	{invar.ErrDupData, invar.E409Duplicate},
+	{invar.ErrDupAccount, invar.E409Duplicate},
+	{invar.ErrDupName, invar.E409Duplicate},
+	{invar.ErrDupKey, invar.E409Duplicate},
+	{invar.ErrDupEntry, invar.E409Duplicate},
+	{invar.ErrInvalidState, invar.E412InvalidState},
+	{invar.ErrUnperparedState, invar.E412InvalidState},
+}
+
+// Lock to register and read error status mapping concurrently.
+var errStatusLock sync.RWMutex
+
+// Register or change the response status of given error for typed handlers.
+//
+//	mvc.RegisterErrorStatus(invar.ErrNotChanged, invar.E412InvalidState)
+func RegisterErrorStatus(err invar.WingErr, status int) {
+	errStatusLock.Lock()
+	defer errStatusLock.Unlock()
+	for i := range errStatus {
+		if errStatus[i].err.Error() == err.Error() {
+			errStatus[i].status = status
+			return
+		}
+	}
+	errStatus = append(errStatus, struct {
+		err    invar.WingErr
+		status int
+	}{err, status})
+}
+
+// Return the response status of given error, it check StatusError interface
+// first, then match the registered invar.WingErr errors, the replic errors
+// created by WingErr.Replic() also matched.
+func ErrorStatus(err error) int {
+	if err == nil {
+		return invar.StatusOK
+	}
+
+	var se StatusError
+	if errors.As(err, &se) {
+		return se.Status()
+	}
+
+	errStatusLock.RLock()
+	defer errStatusLock.RUnlock()
+	msg := err.Error()
+	for _, es := range errStatus {
+		if errors.Is(err, es.err) {
+			return es.status
+		} else if em := es.err.Error(); msg == em || strings.HasPrefix(msg, em+" - ") {
+			return es.status
+		}
+	}
+	return invar.E404Exception
+}
+
+/* ------------------------------------------------------------------- */
+/* For Typed Handlers                                                  */
+/* ------------------------------------------------------------------- */
+
+// Bind input params, validate and execute typed handler, then response
+// the typed result as json (or by WithDataType option), or response the
+// mapped status of returned error.
+//
+// The input params parsed from url for GET, HEAD and DELETE methods, and
+// unmarshal from request body for others, use mvc.NoParams as In type
+// to skip params binding.
+//
+//	@Return 400: Invalid input params (error fields or validate error).
+//	@Return 404: Server internal error.
+//
+// # USAGE:
+//
+//	//	@Description Restful api bind with /detail on POST method
+//	//	@Param data body types.AccID true "input param description"
+//	//	@Success 200 {object} types.Detail "response data description"
+//	//	@router /detail [post]
+//	func (c *AccController) AccDetail() {
+//		mvc.Handle(c, func(ctx context.Context, in *types.AccID) (*types.Detail, error) {
+//			return service.AccDetail(in.UID)
+//		})
+//	}
+func Handle[In, Out any](h WingHandler, fn HandleFunc[In, Out], opts ...Option) {
+	c, options := h.wing(), parseOptions(true, opts...)
+	if in, ok := bindParams[In](c, options); ok {
+		out, err := fn(c.Ctx.Request.Context(), in)
+		c.responTyped(options, out, err)
+	}
+}
+
+// Authenticate request headers by WAuthController or WRoleController, then
+// bind input params and execute typed handler, see Handle() for more infos.
+//
+//	@Return 400: Invalid input params (error fields or validate error).
+//	@Return 401: Unsupport author header or invalid token.
+//	@Return 403: API access permission denied.
+//	@Return 404: Server internal error.
+//
+// # USAGE:
+//
+//	func (c *AccController) AccDetail() {
+//		mvc.HandleAuth(c, func(ctx context.Context, a *mvc.WAuths, in *mvc.NoParams) (*types.Detail, error) {
+//			return service.AccDetail(a.UID)
+//		})
+//	}
+//
+// # NOTICE:
+//
+// The WAuths.ID set as -1 and WAuths.Role is empty for WAuthController.
+func HandleAuth[In, Out any](h AuthHandler, fn AuthHandleFunc[In, Out], opts ...Option) {
+	c, options := h.wing(), parseOptions(true, opts...)
+	if a := h.authRequest(options.Silent); a != nil {
+		if in, ok := bindParams[In](c, options); ok {
+			out, err := fn(c.Ctx.Request.Context(), a, in)
+			c.responTyped(options, out, err)
+		}
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Return WingController self for typed handlers.
+func (c *WingController) wing() *WingController { return c }
+
+// Authenticate header and return account secures for typed handlers.
+func (c *WAuthController) authRequest(silent bool) *WAuths {
+	if uid, pwd := c.innerAuthHeader(silent); uid != "" {
+		return &WAuths{ID: -1, UID: uid, Pwd: pwd}
+	}
+	return nil
+}
+
+// Authenticate header and return account secures for typed handlers.
+func (c *WRoleController) authRequest(silent bool) *WAuths {
+	return c.AuthRequestHeader(silent)
+}
+
+// Bind and validate input params from request url or body.
+func bindParams[In any](c *WingController, opts *Options) (*In, bool) {
+	in := new(In)
+	if _, ok := any(in).(*NoParams); ok {
+		return in, true
+	}
+
+	switch c.Ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return in, c.validateUrlParams(in, opts.validate)
+	}
+	return in, c.validateParams(in, opts)
+}
+
+// Response typed result or mapped status of returned error, the error
+// always logged even not response to frontend in protect mode.
+func (c *WingController) responTyped(opts *Options, out any, err error) {
+	if err != nil {
+		state := ErrorStatus(err)
+		ctl, act := c.GetControllerAndAction()
+		logger.E("Handle", ctl+"."+act, "state:", state, "err:", err)
+		c.responCheckState(opts, state, err.Error())
+		return
+	}
+	c.responCheckState(opts, invar.StatusOK, out)
+}
@@ -11,7 +11,9 @@
 package mvc
 
 type Options struct {
-	datatype string // Response data type, default 'json', set one of 'json', 'jsonp', 'xml', 'yaml'.
+	datatype string // Response data type, default 'json', set one of 'json', 'jsonp', 'xml', 'yaml', 'msgpack', 'cbor'.
+	typed    bool   // Response data type fixed by options, not negotiate by Accept header.
+	nego     bool   // Negotiate response data type by Accept header, default EnableNegotiate.
 	validate bool   // Need validate parsed input params.
 	datas    any    // Response datas for Respon() function options.
 
@@ -23,7 +25,13 @@ type Option func(*Options)
 
 func WithDataType(datatype string) Option {
 	return func(opts *Options) {
-		opts.datatype = datatype
+		opts.datatype, opts.typed = datatype, datatype != ""
+	}
+}
+
+func WithNegotiate(negotiate bool) Option {
+	return func(opts *Options) {
+		opts.nego = negotiate
 	}
 }
 
@@ -47,13 +55,13 @@ func WithSilent(silent bool) Option {
 
 func newOptions(protect, silent bool) *Options {
 	return &Options{
-		datatype: "json",
-		Protect:  protect, Silent: silent,
+		datatype: "json", nego: EnableNegotiate,
+		Protect: protect, Silent: silent,
 	}
 }
 
 func parseOptions(validate bool, options ...Option) *Options {
-	opts := &Options{validate: validate, Protect: true}
+	opts := &Options{validate: validate, nego: EnableNegotiate, Protect: true}
 	for _, optFunc := range options {
 		optFunc(opts)
 	}
@@ -65,6 +73,6 @@ func parseOptions(validate bool, options ...Option) *Options {
 }
 
 func (opts *Options) outType(datatype string) *Options {
-	opts.datatype = datatype
+	opts.datatype, opts.typed = datatype, true
 	return opts
 }
@@ -0,0 +1,240 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mvc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/astaxie/beego"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/wengoldx/xcore/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Response body compress threshold in bytes, it will compress response body
+// by brotli or gzip (depending on request Accept-Encoding header) when body
+// size over the threshold, set 0 or negative to disable compression.
+var CompressThreshold = 1024
+
+// Enable to output ETag header for GET responses, and response 304 without
+// body when the request If-None-Match header matched.
+var EnableETag = true
+
+// Enable to negotiate response data type by request Accept header for all
+// apis, it can be changed for single api by WithNegotiate() option.
+var EnableNegotiate = false
+
+// Response data types and the content types for encode response datas.
+var respContentTypes = map[string]string{
+	"json":    "application/json; charset=utf-8",
+	"xml":     "application/xml; charset=utf-8",
+	"yaml":    "application/x-yaml; charset=utf-8",
+	"msgpack": "application/msgpack",
+	"cbor":    "application/cbor",
+}
+
+// Accept header media types mapping to response data types.
+var acceptDataTypes = map[string]string{
+	"application/json":        "json",
+	"text/json":               "json",
+	"application/xml":         "xml",
+	"text/xml":                "xml",
+	"application/x-yaml":      "yaml",
+	"application/yaml":        "yaml",
+	"text/yaml":               "yaml",
+	"application/msgpack":     "msgpack",
+	"application/x-msgpack":   "msgpack",
+	"application/vnd.msgpack": "msgpack",
+	"application/cbor":        "cbor",
+}
+
+// Return the response data type by request Accept header, it return the
+// default data type unless the client top preferred media type is supported
+// and ranked strictly above the default data type.
+//
+//	Accept: application/msgpack, application/json;q=0.8
+//	// => 'msgpack'
+//	Accept: text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8
+//	// => default, the top preferred 'text/html' not supported.
+func negotiateDataType(accept, def string) string {
+	best, top, bestq, defq := "", 0.0, 0.0, 0.0
+	for _, item := range strings.Split(accept, ",") {
+		mime, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		mime = strings.ToLower(strings.TrimSpace(mime))
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		top = max(top, quality)
+
+		switch datatype, ok := acceptDataTypes[mime]; {
+		case mime == "*/*" || mime == "application/*":
+			defq = max(defq, quality)
+		case !ok:
+			continue
+		case datatype == def:
+			defq = max(defq, quality)
+		case quality > bestq:
+			best, bestq = datatype, quality
+		}
+	}
+
+	if best != "" && bestq == top && bestq > defq {
+		return best
+	}
+	return def
+}
+
+// Encode response data by the given data type, and return the content type.
+func encodeRespData(datatype string, data any) ([]byte, string, error) {
+	var body []byte
+	var err error
+
+	indent := beego.BConfig.RunMode != beego.PROD
+	switch datatype {
+	case "json":
+		if indent {
+			body, err = json.MarshalIndent(data, "", "  ")
+		} else {
+			body, err = json.Marshal(data)
+		}
+	case "xml":
+		if indent {
+			body, err = xml.MarshalIndent(data, "", "  ")
+		} else {
+			body, err = xml.Marshal(data)
+		}
+	case "yaml":
+		body, err = yaml.Marshal(data)
+	case "msgpack":
+		body, err = msgpack.Marshal(data)
+	case "cbor":
+		body, err = cbor.Marshal(data)
+	}
+	return body, respContentTypes[datatype], err
+}
+
+// Return the content encoding accepted by request, prefer 'br' than 'gzip'.
+func acceptEncoding(header string) string {
+	gz := false
+	for _, item := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		if strings.ReplaceAll(params, " ", "") == "q=0" {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "br":
+			return "br"
+		case "gzip":
+			gz = true
+		}
+	}
+	return utils.Condition(gz, "gzip", "")
+}
+
+// Compress the body datas by 'br' or 'gzip' encoding.
+func compressBody(encoding string, body []byte) ([]byte, error) {
+	var w io.WriteCloser
+	buf := &bytes.Buffer{}
+	switch encoding {
+	case "br":
+		w = brotli.NewWriterLevel(buf, brotli.DefaultCompression)
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	default:
+		return body, nil
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	} else if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Return a weak ETag of response body, it keep same for compressed body.
+func newETag(body []byte) string {
+	hash := sha1.Sum(body)
+	return "W/\"" + hex.EncodeToString(hash[:]) + "\""
+}
+
+// Check the If-None-Match request header whether matched the ETag.
+func matchETag(header, etag string) bool {
+	for _, item := range strings.Split(header, ",") {
+		if item = strings.TrimSpace(item); item == "*" || strings.TrimPrefix(item, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode response data and write to client, it output ETag for GET method,
+// and compress body when over CompressThreshold size.
+func (c *WingController) serveEncoded(datatype string, state int, data any, negotiated bool) {
+	body, contenttype, err := encodeRespData(datatype, data)
+	if err != nil {
+		http.Error(c.Ctx.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	output, request := c.Ctx.Output, c.Ctx.Request
+	output.Header("Content-Type", contenttype)
+	vary, compress := []string{}, CompressThreshold > 0 && len(body) >= CompressThreshold
+	if negotiated {
+		vary = append(vary, "Accept")
+	}
+	if compress {
+		vary = append(vary, "Accept-Encoding")
+	}
+	if len(vary) > 0 {
+		output.Header("Vary", strings.Join(vary, ", "))
+	}
+
+	// check If-None-Match header and response 304 without body.
+	if EnableETag && state == http.StatusOK && request.Method == http.MethodGet {
+		etag := newETag(body)
+		output.Header("ETag", etag)
+		if matchETag(request.Header.Get("If-None-Match"), etag) {
+			c.Ctx.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if compress {
+		if encoding := acceptEncoding(request.Header.Get("Accept-Encoding")); encoding != "" {
+			if compressed, err := compressBody(encoding, body); err == nil {
+				output.Header("Content-Encoding", encoding)
+				body = compressed
+			}
+		}
+	}
+	output.Header("Content-Length", strconv.Itoa(len(body)))
+	c.Ctx.ResponseWriter.WriteHeader(state)
+	c.Ctx.ResponseWriter.Write(body)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mvc
+
+import (
+	"crypto/hmac"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+	"github.com/wengoldx/xcore/secure"
+	"github.com/wengoldx/xcore/utils/xhttp"
+)
+
+// Nonce cache to reject replayed signed requests, Seen return true when
+// the key already used in ttl duration, or mark it used and return false.
+type NonceCache interface {
+	Seen(key string, ttl time.Duration) bool
+}
+
+// Options of request signature verify filter.
+type SignOptions struct {
+	Secrets    map[string]string      // Static access key to secret mapping
+	SecretFunc func(ak string) string // Secret finder for keys not in Secrets, optional
+	Skew       time.Duration          // Max clock skew of timestamp, default 5 minutes
+	Nonces     NonceCache             // Nonce cache, default in-memory cache
+}
+
+// In-memory nonce cache, expired nonces cleared when marking new nonces.
+type memNonces struct {
+	lock    sync.Mutex
+	nonces  map[string]time.Time
+	cleared time.Time
+}
+
+// The context input data key of verified access key.
+const SignAccessKey = "accesskey"
+
+// Create in-memory nonce cache, use redis or other shared cache to implement
+// NonceCache when multiple service instances deployed.
+func NewNonceCache() NonceCache {
+	return &memNonces{nonces: make(map[string]time.Time)}
+}
+
+// Check and mark nonce key used.
+func (m *memNonces) Seen(key string, ttl time.Duration) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.cleared) > ttl {
+		for k, expire := range m.nonces {
+			if now.After(expire) {
+				delete(m.nonces, k)
+			}
+		}
+		m.cleared = now
+	}
+
+	if expire, ok := m.nonces[key]; ok && now.Before(expire) {
+		return true
+	}
+	m.nonces[key] = now.Add(ttl)
+	return false
+}
+
+// Create beego filter to verify request signature signed by xhttp.SignFunc(),
+// it response 401 error state when signature invalid, expired or replayed,
+// and set the verified access key into input datas.
+//
+//	beego.InsertFilter("/internal/*", beego.BeforeRouter, mvc.SignatureFilter(&mvc.SignOptions{
+//		Secrets: map[string]string{"order-service": secret},
+//	}))
+//
+//	// get access key in controller.
+//	ak := c.Ctx.Input.GetData(mvc.SignAccessKey).(string)
+func SignatureFilter(opts *SignOptions) beego.FilterFunc {
+	if opts.Skew <= 0 {
+		opts.Skew = 5 * time.Minute
+	}
+	if opts.Nonces == nil {
+		opts.Nonces = NewNonceCache()
+	}
+
+	return func(ctx *context.Context) {
+		ak, err := verifySignature(ctx, opts)
+		if err != nil {
+			logger.E("Verify signature ERR:", ctx.Input.Method(), ctx.Input.URL(), "ak:", ak, err)
+			ctx.ResponseWriter.WriteHeader(invar.E401Unauthorized)
+			ctx.ResponseWriter.Write([]byte(""))
+			return
+		}
+		ctx.Input.SetData(SignAccessKey, ak)
+	}
+}
+
+// Verify request signature headers, timestamp and nonce, then return access key.
+func verifySignature(ctx *context.Context, opts *SignOptions) (string, error) {
+	in := ctx.Input
+	ak, ts, nonce := in.Header(xhttp.HeaderAccessKey), in.Header(xhttp.HeaderTimestamp), in.Header(xhttp.HeaderNonce)
+	signature := in.Header(xhttp.HeaderSignature)
+	if ak == "" || ts == "" || nonce == "" || signature == "" {
+		return ak, invar.ErrInvalidParams
+	}
+
+	secret, ok := opts.Secrets[ak]
+	if !ok && opts.SecretFunc != nil {
+		secret = opts.SecretFunc(ak)
+	}
+	if secret == "" {
+		return ak, invar.ErrNotFound
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ak, invar.ErrInvalidParams
+	} else if skew := time.Since(time.Unix(unix, 0)); skew > opts.Skew || skew < -opts.Skew {
+		return ak, invar.ErrTokenExpired
+	}
+
+	if len(in.RequestBody) == 0 {
+		in.CopyBody(beego.BConfig.MaxMemory)
+	}
+	bodyhash := xhttp.HashBody(in.RequestBody)
+	if hash := in.Header(xhttp.HeaderContentHash); hash != "" && !hmac.Equal([]byte(hash), []byte(bodyhash)) {
+		return ak, invar.ErrInvalidData
+	}
+
+	req := ctx.Request
+	canonical := xhttp.CanonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.RawQuery, bodyhash, ak, ts, nonce)
+	if !hmac.Equal([]byte(secure.SignSHA256(secret, canonical)), []byte(signature)) {
+		return ak, invar.ErrInvalidToken
+	}
+
+	// mark nonce after signature verified, keep it until timestamp out of skew.
+	if opts.Nonces.Seen(ak+":"+nonce, 2*opts.Skew) {
+		return ak, invar.ErrInvalidState
+	}
+	return ak, nil
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package mvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+)
+
+// Server-Sent Event pushed to clients, the Data will output as string for
+// string and []byte types, or marshal as json string for others.
+type SSEvent struct {
+	ID    int64  // Event id increased by topic, set by broker when publish
+	Event string // Event name, optional, default 'message' on client
+	Data  any    // Event datas
+}
+
+// Events broker to manage topics and subscribers, it cache the latest events
+// of each topic to replay for reconnected clients by Last-Event-ID header,
+// and the idle topics without subscribers expired after sseIdleExpires.
+//
+// # USAGE:
+//
+//	var progress = mvc.NewSSEBroker(64, 15 * time.Second)
+//
+//	//	@Description Subscribe task progress events
+//	//	@Param Author header string true "WENGOLD-V1.2"
+//	//	@Param Token  header string true "Authentication token"
+//	//	@router /progress [get]
+//	func (c *TaskController) Progress() {
+//		c.ServeAuthEvents(progress, func(uid string) string {
+//			return "task:" + uid // the topic of current account.
+//		})
+//	}
+//
+//	// publish progress event on task queue.
+//	progress.Publish("task:" + uid, "progress", &types.Progress{Done: 10, Total: 100})
+//
+// # WARNING:
+//
+// The beego.BConfig.Listen.ServerTimeOut must set 0 (default) to keep
+// events stream alive without write timeout.
+type SSEBroker struct {
+	mutex     sync.RWMutex
+	topics    map[string]*sseTopic
+	buffers   int           // Max cached events of each topic for replay
+	heartbeat time.Duration // Heartbeat interval, not send when <= 0
+	swept     time.Time     // Last time of sweep idle topics
+}
+
+// Topic cached events and subscribers.
+type sseTopic struct {
+	lastid int64
+	events []*SSEvent
+	subers map[chan *SSEvent]struct{}
+	idle   time.Time // Time of topic created or the last subscriber left
+}
+
+// Max pending events of each subscriber, the slow subscriber will be
+// disconnected when overflow, then it can reconnect and replay events.
+const ssePendings = 32
+
+// Expiration of idle topics without subscribers, the cached events of
+// expired topics cleared and can not replay any more.
+const sseIdleExpires = 10 * time.Minute
+
+// Create a events broker with max cached events of each topic, and heartbeat
+// interval to keep connections alive through proxies.
+func NewSSEBroker(buffers int, heartbeat time.Duration) *SSEBroker {
+	return &SSEBroker{
+		topics: make(map[string]*sseTopic), buffers: buffers, heartbeat: heartbeat,
+	}
+}
+
+// Publish event to all subscribers of topic, and return the event id.
+func (b *SSEBroker) Publish(topic, event string, data any) int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.sweepTopics()
+	t := b.ensureTopic(topic)
+	t.lastid++
+	evt := &SSEvent{ID: t.lastid, Event: event, Data: data}
+	if b.buffers > 0 {
+		if t.events = append(t.events, evt); len(t.events) > b.buffers {
+			t.events = t.events[len(t.events)-b.buffers:]
+		}
+	}
+
+	for ch := range t.subers {
+		select {
+		case ch <- evt:
+		default:
+			logger.W("Disconnect slow SSE subscriber of topic:", topic)
+			b.leaveTopic(topic, t, ch)
+		}
+	}
+	return evt.ID
+}
+
+// Subscribe topic events and return the events channel, the cached events
+// which id greater than lastid will send to channel first.
+func (b *SSEBroker) Subscribe(topic string, lastid int64) <-chan *SSEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.sweepTopics()
+	t, ch := b.ensureTopic(topic), make(chan *SSEvent, ssePendings+b.buffers)
+	if lastid > 0 {
+		for _, evt := range t.events {
+			if evt.ID > lastid {
+				ch <- evt
+			}
+		}
+	}
+	t.subers[ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe topic events and close the events channel, the topic will
+// be removed when the last subscriber left and not cache events.
+func (b *SSEBroker) Unsubscribe(topic string, ch <-chan *SSEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if t, ok := b.topics[topic]; ok {
+		for c := range t.subers {
+			if c == ch {
+				b.leaveTopic(topic, t, c)
+				break
+			}
+		}
+	}
+	b.sweepTopics()
+}
+
+// Close topic and disconnect all subscribers, the cached events cleared.
+func (b *SSEBroker) Close(topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if t, ok := b.topics[topic]; ok {
+		for ch := range t.subers {
+			close(ch)
+		}
+		delete(b.topics, topic)
+	}
+}
+
+// Return the subscribers count of topic.
+func (b *SSEBroker) Subscribers(topic string) int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if t, ok := b.topics[topic]; ok {
+		return len(t.subers)
+	}
+	return 0
+}
+
+// Return exist topic or create a new one, call it in locking.
+func (b *SSEBroker) ensureTopic(topic string) *sseTopic {
+	t, ok := b.topics[topic]
+	if !ok {
+		t = &sseTopic{subers: make(map[chan *SSEvent]struct{}), idle: time.Now()}
+		b.topics[topic] = t
+	}
+	return t
+}
+
+// Remove subscriber from topic and close the channel, the idle topic will
+// remove directly when not cache events, call it in locking.
+func (b *SSEBroker) leaveTopic(topic string, t *sseTopic, ch chan *SSEvent) {
+	delete(t.subers, ch)
+	close(ch)
+	if len(t.subers) == 0 {
+		if b.buffers <= 0 {
+			delete(b.topics, topic)
+		}
+		t.idle = time.Now()
+	}
+}
+
+// Remove the expired idle topics at most once per sseIdleExpires, call it
+// in locking.
+func (b *SSEBroker) sweepTopics() {
+	now := time.Now()
+	if now.Sub(b.swept) < sseIdleExpires {
+		return
+	}
+
+	b.swept = now
+	for topic, t := range b.topics {
+		if len(t.subers) == 0 && now.Sub(t.idle) >= sseIdleExpires {
+			delete(b.topics, topic)
+		}
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Events Stream Utils                                             */
+/* ------------------------------------------------------------------- */
+
+// Upgrade request as text/event-stream and push topic events to client
+// until client disconnected or topic closed, it replay cached events
+// after the Last-Event-ID header (or 'lastEventId' url param).
+//
+//	@Return 405: Streaming unsupported by response writer.
+func (c *WingController) ServeEvents(b *SSEBroker, topic string) {
+	w := c.Ctx.ResponseWriter
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		c.E405Disabled("Streaming unsupported!")
+		return
+	}
+
+	lastid := c.lastEventID()
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // disable nginx buffering
+	w.WriteHeader(invar.StatusOK)
+	flusher.Flush()
+
+	ch := b.Subscribe(topic, lastid)
+	defer b.Unsubscribe(topic, ch)
+	logger.I("Subscribed SSE topic:", topic, "from:", lastid)
+
+	var heartbeat <-chan time.Time
+	if b.heartbeat > 0 {
+		ticker := time.NewTicker(b.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	done := c.Ctx.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			logger.I("Disconnected SSE client of topic:", topic)
+			return
+		case <-heartbeat:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case evt, ok := <-ch:
+			if !ok {
+				logger.I("Closed SSE stream of topic:", topic)
+				return
+			}
+			if err := writeEvent(w, evt); err != nil {
+				logger.E("Write SSE event, err:", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Authenticate request headers, then push the events of topic returned by
+// next function to client, see WingController.ServeEvents() for more infos.
+//
+//	@Return 401: Unsupport author header or invalid token.
+//	@Return 403: API access permission denied, or next returned empty topic.
+//	@Return 405: Streaming unsupported by response writer.
+func (c *WAuthController) ServeAuthEvents(b *SSEBroker, next func(uid string) string) {
+	if uid := c.AuthRequestHeader(); uid != "" {
+		if topic := next(uid); topic != "" {
+			c.ServeEvents(b, topic)
+			return
+		}
+		c.E403Denind("Topic denied for " + uid)
+	}
+}
+
+// Authenticate request token and role, then push the events of topic returned
+// by next function to client, see WingController.ServeEvents() for more infos.
+//
+//	@Return 401: Unsupport author header or invalid token.
+//	@Return 403: API access permission denied, or next returned empty topic.
+//	@Return 405: Streaming unsupported by response writer.
+func (c *WRoleController) ServeAuthEvents(b *SSEBroker, next func(a *WAuths) string) {
+	if a := c.AuthRequestHeader(); a != nil {
+		if topic := next(a); topic != "" {
+			c.ServeEvents(b, topic)
+			return
+		}
+		c.E403Denind("Topic denied for " + a.UID)
+	}
+}
+
+// Return the last event id from request header or url param.
+func (c *WingController) lastEventID() int64 {
+	lastid := c.Ctx.Request.Header.Get("Last-Event-ID")
+	if lastid == "" {
+		lastid = c.GetString("lastEventId")
+	}
+	id, _ := strconv.ParseInt(lastid, 10, 64)
+	return id
+}
+
+// Write event as text/event-stream format.
+//
+//	id: 12
+//	event: progress
+//	data: {"done":10,"total":100}
+func writeEvent(w io.Writer, evt *SSEvent) error {
+	var data string
+	switch v := evt.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(buf)
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "id: %d\n", evt.ID)
+	if evt.Event != "" {
+		fmt.Fprintf(sb, "event: %s\n", evt.Event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(sb, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	sb.WriteString("\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -10,6 +10,8 @@
 
 package nacos
 
+import "github.com/wengoldx/xcore/secure"
+
 /* ------------------------------------------------------------------- */
 /* Internal constants defines                                          */
 /* ------------------------------------------------------------------- */
@@ -172,6 +174,25 @@ type GrpcCerts struct {
 	Certs []GrpcCert `xml:"Cert"` // GRPC certs.
 }
 
+// Load GRPC cert chain and private key as keystore.
+func (c *GrpcCert) KeyStore() (*secure.KeyStore, error) {
+	return secure.LoadKeyStore(c.Pem, c.Key)
+}
+
+// Return the GRPC certs expiring within the given days, the invalid certs
+// returned as error to alert too.
+func (c *GrpcCerts) Expiring(days int) ([]*secure.CertExpiry, error) {
+	expiries := []*secure.CertExpiry{}
+	for _, cert := range c.Certs {
+		ks, err := cert.KeyStore()
+		if err != nil {
+			return nil, err
+		}
+		expiries = append(expiries, ks.Expiring(cert.Svr, days)...)
+	}
+	return expiries, nil
+}
+
 /* ------------------------------------------------------------------- */
 /* Export Config Data: DID_MIO_PATHS                                   */
 /* ------------------------------------------------------------------- */
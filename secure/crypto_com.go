@@ -24,6 +24,7 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -43,8 +44,8 @@ const (
 	passwordHashBytes   = 64 // default password hash length
 )
 
-// For generate uid string
-var uuidNode *snowflake.Node
+// For generate uid string, setup by app.conf, see SetupIDGenerator().
+var uuidNode atomic.Pointer[snowflake.Node]
 var rander *rand.Rand
 
 // For loop id use in runtime.
@@ -56,13 +57,7 @@ const _max_loop_id = 1000000
 // init uid generater
 func init() {
 	rander = rand.New(rand.NewSource(time.Now().UnixNano()))
-	if uuidNode == nil {
-		node, err := snowflake.NewNode(1)
-		if err != nil {
-			panic(err)
-		}
-		uuidNode = node
-	}
+	setupIDGenerator()
 }
 
 // Convert the number into a string of the specified radix.
@@ -114,14 +109,14 @@ func CodeToNum(code string, mapping string) int64 {
 	return num
 }
 
-// Create a new uid in int64
+// Create a new uid in int64, see SetNodeID() to set snowflake node.
 func NewUID() int64 {
-	return uuidNode.Generate().Int64()
+	return uuidNode.Load().Generate().Int64()
 }
 
-// Create a new uid in string
+// Create a new uid in string, see SetNodeID() to set snowflake node.
 func NewSUID() string {
-	return uuidNode.Generate().String()
+	return uuidNode.Load().Generate().String()
 }
 
 // Create a random number uid with specified digits
@@ -0,0 +1,392 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+
+	"github.com/wengoldx/xcore/invar"
+	"golang.org/x/crypto/hkdf"
+)
+
+/**
+ * It's a Curve25519 secure utils to sign data by Ed25519 keys, and seal data
+ * to recipient by X25519 ECDH key agreement.
+ *
+ * ---
+ *
+ * USAGE:
+ *
+ * 1. Call secure.NewEd25519Keys() create private and public keys pem datas to save.
+ * 2. Call secure.Ed25519Sign(plaintext, prikey) sign plaintext.
+ * 3. Call secure.Ed25519Verify(plaintext, signstring, pubkey) to verify valid.
+ *
+ * 4. Call secure.NewX25519Keys() create recipient private and public keys pem datas.
+ * 5. Call secure.X25519Seal(pubkey, data) seal data to recipient public key.
+ * 6. Call secure.X25519Open(prikey, sealed) open sealed data by recipient private key.
+ *
+ * EXTEND:
+ *
+ * - Call secure.Ed25519PriKey(pripem), secure.Ed25519PubKey(pubpem) parse keys from pem data.
+ * - Call secure.Ed25519SeedKey(seed), secure.Ed25519RawPubKey(raw) parse keys from raw datas.
+ * - Call secure.X25519PriKey(pripem), secure.X25519PubKey(pubpem) parse keys from pem data.
+ */
+
+const (
+	PKCS8_PEM_PRI_HEADER = "PRIVATE KEY" // PKCS#8 private key pem file header of Ed25519, X25519
+	PKIX_PEM_PUB_HEADER  = "PUBLIC KEY"  // PKIX public key pem file header of Ed25519, X25519
+
+	x25519SealInfo = "xcore-x25519-seal-v1" // HKDF info of X25519 sealing key
+)
+
+/* ------------------------------------------------------------------- */
+/* For Ed25519 Sign Utils                                              */
+/* ------------------------------------------------------------------- */
+
+// Create a Ed25519 random private key, the pair public key can be get by
+// prikey.Public().(ed25519.PublicKey).
+func NewEd25519PriKey() (ed25519.PrivateKey, error) {
+	_, prikey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return prikey, nil
+}
+
+// Create Ed25519 private key, and format private and public keys as pem strings.
+func NewEd25519Keys() (string, string, error) {
+	prikey, err := NewEd25519PriKey()
+	if err != nil {
+		return "", "", err
+	}
+	return Ed25519KeysString(prikey)
+}
+
+// Create Ed25519 private key and save to target pem file.
+func NewEd25519PemFile(outfile string) error {
+	if prikey, err := NewEd25519PriKey(); err != nil {
+		return err
+	} else if pripem, err := Ed25519PriString(prikey); err != nil {
+		return err
+	} else {
+		return os.WriteFile(outfile, []byte(pripem), 0666)
+	}
+}
+
+// Load Ed25519 private pem file and return private key.
+func LoadEd25519PemFile(pemfile string) (ed25519.PrivateKey, error) {
+	pripem, err := os.ReadFile(pemfile)
+	if err != nil {
+		return nil, err
+	}
+	return Ed25519PriKey(string(pripem))
+}
+
+// Format Ed25519 private key to PKCS#8 pem string.
+func Ed25519PriString(prikey ed25519.PrivateKey) (string, error) {
+	return marshalPKCS8Pem(prikey)
+}
+
+// Format Ed25519 public key to PKIX pem string.
+func Ed25519PubString(pubkey ed25519.PublicKey) (string, error) {
+	return marshalPKIXPem(pubkey)
+}
+
+// Format Ed25519 private and public keys to pem strings.
+func Ed25519KeysString(prikey ed25519.PrivateKey) (string, string, error) {
+	if pripem, err := Ed25519PriString(prikey); err != nil {
+		return "", "", err
+	} else if pubpem, err := Ed25519PubString(prikey.Public().(ed25519.PublicKey)); err != nil {
+		return "", "", err
+	} else {
+		return pripem, pubpem, nil
+	}
+}
+
+// Get Ed25519 private key from PKCS#8 private pem string.
+func Ed25519PriKey(pripem string) (ed25519.PrivateKey, error) {
+	key, err := parsePKCS8Pem(pripem)
+	if err != nil {
+		return nil, err
+	}
+
+	prikey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, invar.ErrBadPriKey
+	}
+	return prikey, nil
+}
+
+// Get Ed25519 public key from PKIX public pem string.
+func Ed25519PubKey(pubpem string) (ed25519.PublicKey, error) {
+	key, err := parsePKIXPem(pubpem)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, invar.ErrBadPubKey
+	}
+	return pubkey, nil
+}
+
+// Get Ed25519 private key from 32 bytes raw seed, the seed can be export
+// by prikey.Seed().
+func Ed25519SeedKey(seed []byte) (ed25519.PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, invar.ErrBadPriKey
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Get Ed25519 public key from 32 bytes raw key, the raw key can be export
+// by []byte(pubkey).
+func Ed25519RawPubKey(raw []byte) (ed25519.PublicKey, error) {
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, invar.ErrBadPubKey
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Sign the given plaintext by Ed25519 private key, and return the signed
+// code on base64 format.
+//
+//	prikey, _ := NewEd25519PriKey()
+//	plaintext := "This is a plainttext to sign and verfiy!"
+//	signb64, _ := Ed25519Sign(plaintext, prikey)
+//	valid, _ : Ed25519Verify(plaintext, signb64, prikey.Public().(ed25519.PublicKey))
+//	fmt.Println("Ed25519 verify result:", valid)
+//
+// # WARNING:
+//   - Use the same plaintext and Ed25519 private key to sign, it always
+//     output the same sign strings.
+func Ed25519Sign(plaintext string, prikey ed25519.PrivateKey) (string, error) {
+	if len(prikey) != ed25519.PrivateKeySize {
+		return "", invar.ErrBadPriKey
+	}
+	return ByteToBase64(ed25519.Sign(prikey, []byte(plaintext))), nil
+}
+
+// Verify the given plaintext by Ed25519 public key and base64 formated sign code.
+func Ed25519Verify(plaintext, signb64 string, pubkey ed25519.PublicKey) (bool, error) {
+	if len(pubkey) != ed25519.PublicKeySize {
+		return false, invar.ErrBadPubKey
+	}
+
+	signs, err := Base64ToByte(signb64)
+	if err != nil {
+		return false, err
+	}
+	return ed25519.Verify(pubkey, []byte(plaintext), signs), nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For X25519 Key Agreement Utils                                      */
+/* ------------------------------------------------------------------- */
+
+// Create a X25519 random private key, the pair public key can be get by
+// prikey.PublicKey().
+func NewX25519PriKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// Create X25519 private key, and format private and public keys as pem strings.
+func NewX25519Keys() (string, string, error) {
+	prikey, err := NewX25519PriKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	if pripem, err := marshalPKCS8Pem(prikey); err != nil {
+		return "", "", err
+	} else if pubpem, err := marshalPKIXPem(prikey.PublicKey()); err != nil {
+		return "", "", err
+	} else {
+		return pripem, pubpem, nil
+	}
+}
+
+// Get X25519 private key from PKCS#8 private pem string.
+func X25519PriKey(pripem string) (*ecdh.PrivateKey, error) {
+	key, err := parsePKCS8Pem(pripem)
+	if err != nil {
+		return nil, err
+	}
+
+	prikey, ok := key.(*ecdh.PrivateKey)
+	if !ok || prikey.Curve() != ecdh.X25519() {
+		return nil, invar.ErrBadPriKey
+	}
+	return prikey, nil
+}
+
+// Get X25519 public key from PKIX public pem string.
+func X25519PubKey(pubpem string) (*ecdh.PublicKey, error) {
+	key, err := parsePKIXPem(pubpem)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkey, ok := key.(*ecdh.PublicKey)
+	if !ok || pubkey.Curve() != ecdh.X25519() {
+		return nil, invar.ErrBadPubKey
+	}
+	return pubkey, nil
+}
+
+// Agree the shared secret of local private key and peer public key, then
+// derive a 32 bytes AES key by HKDF-SHA256 with optional salt and info.
+//
+//	// both sides output the same key.
+//	key1, _ := secure.X25519SharedKey(alice, bob.PublicKey(), salt, "chat")
+//	key2, _ := secure.X25519SharedKey(bob, alice.PublicKey(), salt, "chat")
+func X25519SharedKey(prikey *ecdh.PrivateKey, pubkey *ecdh.PublicKey, salt []byte, info string) ([]byte, error) {
+	secret, err := prikey.ECDH(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal data to recipient X25519 public key, and return base64 formated
+// sealed string, only the recipient private key holder can open it.
+//
+// The sealed datas formated as ephemeral public key (32) | nonce (12) | ciphertext,
+// and the AES-256-GCM key derived from ECDH secret of ephemeral private key.
+//
+//	sealed, _ := secure.X25519Seal(pubkey, []byte("secret message"))
+//	data, _ := secure.X25519Open(prikey, sealed)
+func X25519Seal(pubkey *ecdh.PublicKey, data []byte, aad ...[]byte) (string, error) {
+	ephemeral, err := NewX25519PriKey()
+	if err != nil {
+		return "", err
+	}
+
+	eph := ephemeral.PublicKey().Bytes()
+	aead, err := x25519SealAEAD(ephemeral, pubkey, eph)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append(append(eph, nonce...), aead.Seal(nil, nonce, data, sealAAD(aad))...)
+	return ByteToBase64(sealed), nil
+}
+
+// Open base64 formated sealed string by recipient X25519 private key.
+func X25519Open(prikey *ecdh.PrivateKey, sealedb64 string, aad ...[]byte) ([]byte, error) {
+	sealed, err := Base64ToByte(sealedb64)
+	if err != nil {
+		return nil, err
+	} else if len(sealed) < 32+12+16 {
+		return nil, invar.ErrInvalidData
+	}
+
+	eph := sealed[:32]
+	ephkey, err := ecdh.X25519().NewPublicKey(eph)
+	if err != nil {
+		return nil, invar.ErrBadPubKey
+	}
+
+	aead, err := x25519SealAEAD(prikey, ephkey, eph)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, ciphertext := sealed[32:32+aead.NonceSize()], sealed[32+aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, ciphertext, sealAAD(aad))
+	if err != nil {
+		return nil, invar.ErrInvalidData
+	}
+	return data, nil
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Create AES-256-GCM by the key agreed of private and public keys, the
+// ephemeral public key used as HKDF salt.
+func x25519SealAEAD(prikey *ecdh.PrivateKey, pubkey *ecdh.PublicKey, eph []byte) (cipher.AEAD, error) {
+	key, err := X25519SharedKey(prikey, pubkey, eph, x25519SealInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Return the first additional data or nil.
+func sealAAD(aad [][]byte) []byte {
+	if len(aad) > 0 {
+		return aad[0]
+	}
+	return nil
+}
+
+// Marshal private key to PKCS#8 pem string.
+func marshalPKCS8Pem(prikey any) (string, error) {
+	dertext, err := x509.MarshalPKCS8PrivateKey(prikey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: PKCS8_PEM_PRI_HEADER, Bytes: dertext})), nil
+}
+
+// Marshal public key to PKIX pem string.
+func marshalPKIXPem(pubkey any) (string, error) {
+	dertext, err := x509.MarshalPKIXPublicKey(pubkey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: PKIX_PEM_PUB_HEADER, Bytes: dertext})), nil
+}
+
+// Parse PKCS#8 private key from pem string.
+func parsePKCS8Pem(pripem string) (any, error) {
+	block, _ := pem.Decode([]byte(pripem))
+	if block == nil {
+		return nil, invar.ErrBadPriKey
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// Parse PKIX public key from pem string.
+func parsePKIXPem(pubpem string) (any, error) {
+	block, _ := pem.Decode([]byte(pubpem))
+	if block == nil {
+		return nil, invar.ErrBadPubKey
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
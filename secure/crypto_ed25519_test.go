@@ -0,0 +1,119 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/secure, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Test NewEd25519Keys, Ed25519PriKey, Ed25519PubKey, Ed25519Sign, Ed25519Verify.
+func TestEd25519SignVerify(t *testing.T) {
+	pripem, pubpem, err := NewEd25519Keys()
+	if err != nil {
+		t.Fatal("New Ed25519 keys, err:", err)
+	}
+
+	prikey, _ := Ed25519PriKey(pripem)
+	pubkey, _ := Ed25519PubKey(pubpem)
+	seedkey, _ := Ed25519SeedKey(prikey.Seed())
+	rawpub, _ := Ed25519RawPubKey([]byte(pubkey))
+	if !bytes.Equal(seedkey, prikey) || !bytes.Equal(rawpub, prikey.Public().(ed25519.PublicKey)) {
+		t.Fatal("Unmatched raw keys!")
+	}
+
+	cases := []struct {
+		Case      string
+		Plaintext string
+		Verify    string
+		Want      bool
+	}{
+		{"Verify same plaintext", "This is a plaintext!", "This is a plaintext!", true},
+		{"Verify other plaintext", "This is a plaintext!", "This is a plaintext?", false},
+		{"Verify empty plaintext", "", "", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			sign, err := Ed25519Sign(c.Plaintext, prikey)
+			if err != nil {
+				t.Fatal("Ed25519 sign, err:", err)
+			}
+
+			if valid, _ := Ed25519Verify(c.Verify, sign, pubkey); valid != c.Want {
+				t.Fatal("Ed25519 verify:", valid, "want:", c.Want)
+			}
+		})
+	}
+
+	// sign and verify by seed sign.
+	plaintext := []string{"This a plaintext!", "Second text"}
+	ss := SeedSign{}
+	sign, _ := ss.Ed25519Sign(pripem, plaintext...)
+	if sign2, _ := ss.Ed25519Sign(pripem, plaintext...); sign != sign2 {
+		t.Fatal("Exist different signs (Ed25519)!!")
+	} else if valid, _ := ss.Ed25519Verify(sign, pubpem, plaintext...); !valid {
+		t.Fatal("Failed verify Ed25519 sign!")
+	}
+}
+
+// Test NewX25519Keys, X25519PriKey, X25519PubKey, X25519Seal, X25519Open.
+func TestX25519SealOpen(t *testing.T) {
+	pripem, pubpem, err := NewX25519Keys()
+	if err != nil {
+		t.Fatal("New X25519 keys, err:", err)
+	}
+	prikey, _ := X25519PriKey(pripem)
+	pubkey, _ := X25519PubKey(pubpem)
+	other, _ := NewX25519PriKey()
+
+	cases := []struct {
+		Case string
+		Data string
+		AAD  string
+		Open string
+		Want bool
+	}{
+		{"Open by recipient key", "secret message", "", "", true},
+		{"Open with same aad", "secret message", "uid-1", "uid-1", true},
+		{"Open with other aad", "secret message", "uid-1", "uid-2", false},
+		{"Open empty data", "", "", "", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			sealed, err := X25519Seal(pubkey, []byte(c.Data), []byte(c.AAD))
+			if err != nil {
+				t.Fatal("X25519 seal, err:", err)
+			}
+
+			data, err := X25519Open(prikey, sealed, []byte(c.Open))
+			if (err == nil && string(data) == c.Data) != c.Want {
+				t.Fatal("X25519 open:", string(data), "err:", err)
+			} else if _, err := X25519Open(other, sealed, []byte(c.Open)); err == nil {
+				t.Fatal("Opened by other private key!")
+			}
+		})
+	}
+
+	key1, _ := X25519SharedKey(prikey, other.PublicKey(), nil, "test")
+	key2, _ := X25519SharedKey(other, pubkey, nil, "test")
+	if !bytes.Equal(key1, key2) {
+		t.Fatal("Unmatched shared keys!")
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	crypto "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/bwmarrin/snowflake"
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+)
+
+// ID formats of NewID() generated.
+const (
+	IDSnowflake = "snowflake" // Snowflake int64 number string, default format
+	IDULID      = "ulid"      // 26 chars Crockford base32 ULID, lexicographically sortable
+	IDUUIDv7    = "uuidv7"    // 36 chars time ordered UUID version 7
+)
+
+// Node id sources of snowflake generator.
+const (
+	NodeFromPod = "pod" // Use the ordinal suffix of pod hostname, such as 'acc-3' to 3
+	NodeFromIP  = "ip"  // Use the low 10 bits of local IPv4 address
+)
+
+// Config keys and env of ID generator.
+const (
+	idConfigNode = "idgen::node"   // Node id number, or 'pod', 'ip' sources
+	idConfigFmt  = "idgen::format" // Default ID format of NewID()
+	idEnvNode    = "XCORE_NODE_ID" // Node id env, it override config value
+)
+
+// Crockford base32 chars of ULID.
+const ulidEncoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Monotonic sortable ID generator, the random bits increased by one when
+// generate multiple IDs in the same millisecond.
+type monotonic struct {
+	lock sync.Mutex
+	last int64    // Last timestamp in milliseconds
+	rand [10]byte // Last 80 bits random datas
+}
+
+var (
+	_ulids, _uuids = &monotonic{}, &monotonic{}
+
+	_idlock   sync.RWMutex
+	_idformat = IDSnowflake
+	_idgens   = map[string]func() string{
+		IDSnowflake: NewSUID, IDULID: NewULID, IDUUIDv7: NewUUIDv7,
+	}
+)
+
+// Setup snowflake node and default ID format from app.conf or env, the
+// default node 1 used when not configured, so please config distinct node
+// for each replicas.
+//
+//	[idgen]
+//	; Snowflake node id in 0 ~ 1023, or 'pod', 'ip' to resolve from runtime.
+//	node = pod
+//
+//	; Default format of secure.NewID(), one of snowflake, ulid, uuidv7.
+//	format = ulid
+//
+// The env XCORE_NODE_ID override the node config when it set.
+//
+// # NOTICE:
+//
+// It called when secure package init, and the invalid configs logged then
+// fallback to node 1 and snowflake format, call it again to check the error
+// when need to stop app on invalid configs.
+//
+//	if err := secure.SetupIDGenerator(); err != nil {
+//		panic(err)
+//	}
+func SetupIDGenerator() error {
+	source := beego.AppConfig.String(idConfigNode)
+	if env := os.Getenv(idEnvNode); env != "" {
+		source = env
+	}
+
+	node := int64(1)
+	if source != "" {
+		var err error
+		if node, err = ResolveNodeID(source); err != nil {
+			return fmt.Errorf("resolve snowflake node '%s', err: %v", source, err)
+		}
+	}
+	if err := SetNodeID(node); err != nil {
+		return err
+	}
+
+	if format := beego.AppConfig.String(idConfigFmt); format != "" {
+		if err := SetIDFormat(format); err != nil {
+			return fmt.Errorf("unsupport id format: %s", format)
+		}
+	}
+	return nil
+}
+
+// Setup ID generator when package init, it fallback to node 1 when configs
+// invalid, so the config typo not crash every binaries import secure.
+func setupIDGenerator() {
+	if err := SetupIDGenerator(); err != nil {
+		logger.E("Setup ID generator, err:", err, "- fallback to node 1")
+		SetNodeID(1)
+	}
+}
+
+// Resolve snowflake node id from number string, or the pod, ip sources.
+//
+//	node, _ := secure.ResolveNodeID("12")  // 12
+//	node, _ := secure.ResolveNodeID("pod") // 3 of hostname 'acc-3'
+//	node, _ := secure.ResolveNodeID("ip")  // 293 of ip '192.168.1.37'
+func ResolveNodeID(source string) (int64, error) {
+	switch source = strings.TrimSpace(source); source {
+	case NodeFromPod:
+		hostname := os.Getenv("HOSTNAME")
+		if hostname == "" {
+			hostname, _ = os.Hostname()
+		}
+		return PodNodeID(hostname)
+	case NodeFromIP:
+		return IPNodeID(nil)
+	}
+
+	node, err := strconv.ParseInt(source, 10, 64)
+	if err != nil || node < 0 || node > maxNodeID() {
+		return 0, invar.ErrInvalidConfigs
+	}
+	return node, nil
+}
+
+// Return node id from the ordinal suffix of StatefulSet pod hostname.
+func PodNodeID(hostname string) (int64, error) {
+	idx := strings.LastIndex(hostname, "-")
+	if idx < 0 {
+		return 0, invar.ErrInvalidConfigs
+	}
+
+	node, err := strconv.ParseInt(hostname[idx+1:], 10, 64)
+	if err != nil || node < 0 || node > maxNodeID() {
+		return 0, invar.ErrInvalidConfigs
+	}
+	return node, nil
+}
+
+// Return node id from the low 10 bits of IPv4 address, it use the first
+// none loopback local IPv4 address when ip param is nil.
+//
+// # WARNING:
+//   - The nodes maybe conflict when IPs over a /22 subnet range.
+func IPNodeID(ip net.IP) (int64, error) {
+	if ip == nil {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+				ip = ipnet.IP
+				break
+			}
+		}
+	}
+
+	if ip = ip.To4(); ip == nil {
+		return 0, invar.ErrNotFound
+	}
+	return (int64(ip[2])<<8 | int64(ip[3])) & maxNodeID(), nil
+}
+
+// Reset snowflake node of NewUID() and NewSUID(), it safe to call at runtime
+// when node id changed by nacos configs.
+func SetNodeID(node int64) error {
+	sfnode, err := snowflake.NewNode(node)
+	if err != nil {
+		return invar.ErrInvalidConfigs
+	}
+	uuidNode.Store(sfnode)
+	return nil
+}
+
+// Set the default format of NewID(), the format must be one of IDSnowflake,
+// IDULID, IDUUIDv7, or registered by RegisterIDFormat().
+func SetIDFormat(format string) error {
+	_idlock.Lock()
+	defer _idlock.Unlock()
+
+	if _, ok := _idgens[format]; !ok {
+		return invar.ErrNotSupport
+	}
+	_idformat = format
+	return nil
+}
+
+// Register custom ID generator as format, then set it as default by
+// SetIDFormat() to generate IDs by NewID().
+func RegisterIDFormat(format string, generator func() string) {
+	_idlock.Lock()
+	defer _idlock.Unlock()
+	_idgens[format] = generator
+}
+
+// Create a new ID string by the default format, it same as NewSUID() when
+// not set default format.
+func NewID() string {
+	_idlock.RLock()
+	generator := _idgens[_idformat]
+	_idlock.RUnlock()
+	return generator()
+}
+
+// Create a new ULID string, such as 01JAB6SMZ8Q0E8T2X5YZ3N9RKD, it
+// monotonic increase in current process.
+func NewULID() string {
+	ms, rand := _ulids.next()
+
+	id := make([]byte, 16)
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	copy(id[6:], rand[:])
+	return encodeULID(id)
+}
+
+// Create a new UUID version 7 string, such as 0192a0b4-6fe8-7a3c-9d41-5f0c8e2b7d13,
+// it monotonic increase in current process.
+func NewUUIDv7() string {
+	ms, rand := _uuids.next()
+
+	id := make([]byte, 16)
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	copy(id[6:], rand[:])
+	id[6] = 0x70 | (id[6] & 0x0f) // version 7
+	id[8] = 0x80 | (id[8] & 0x3f) // variant RFC 9562
+
+	buf := hex.EncodeToString(id)
+	return buf[0:8] + "-" + buf[8:12] + "-" + buf[12:16] + "-" + buf[16:20] + "-" + buf[20:]
+}
+
+// Decode snowflake id to generated time, node and step numbers.
+//
+//	ts, node, step := secure.DecodeSnowflake(secure.NewUID())
+func DecodeSnowflake(id int64) (time.Time, int64, int64) {
+	sfid := snowflake.ParseInt64(id)
+	return time.UnixMilli(sfid.Time()), sfid.Node(), sfid.Step()
+}
+
+// Decode snowflake id string to generated time and node number.
+func DecodeSUID(suid string) (time.Time, int64, error) {
+	id, err := strconv.ParseInt(suid, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, invar.ErrInvalidData
+	}
+
+	ts, node, _ := DecodeSnowflake(id)
+	return ts, node, nil
+}
+
+// Decode ULID string to generated time.
+func DecodeULID(ulid string) (time.Time, error) {
+	if len(ulid) != 26 || ulid[0] > '7' {
+		return time.Time{}, invar.ErrInvalidData
+	}
+
+	ms := int64(0)
+	for _, char := range strings.ToUpper(ulid[:10]) {
+		idx := strings.IndexRune(ulidEncoding, char)
+		if idx < 0 {
+			return time.Time{}, invar.ErrInvalidData
+		}
+		ms = ms<<5 | int64(idx)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// Decode UUID version 7 string to generated time.
+func DecodeUUIDv7(uuid string) (time.Time, error) {
+	id, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+	if err != nil || len(id) != 16 || id[6]>>4 != 7 {
+		return time.Time{}, invar.ErrInvalidData
+	}
+
+	ms := int64(binary.BigEndian.Uint16(id[0:2]))<<32 | int64(binary.BigEndian.Uint32(id[2:6]))
+	return time.UnixMilli(ms), nil
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Return the max node id of snowflake.
+func maxNodeID() int64 {
+	return -1 ^ (-1 << snowflake.NodeBits)
+}
+
+// Return the next timestamp and random datas, it increase the last random
+// datas in the same millisecond or clock moved backwards, and move to next
+// millisecond when random datas overflowed.
+func (m *monotonic) next() (int64, [10]byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if ms := time.Now().UnixMilli(); ms > m.last {
+		m.last = ms
+		crypto.Read(m.rand[:])
+		m.rand[0] &= 0x7f // keep the top bit to avoid overflow soon.
+		return m.last, m.rand
+	}
+
+	for i := len(m.rand) - 1; i >= 0; i-- {
+		if m.rand[i]++; m.rand[i] != 0 {
+			return m.last, m.rand
+		}
+	}
+
+	m.last++ // overflowed, borrow next millisecond.
+	return m.last, m.rand
+}
+
+// Encode 16 bytes ULID to 26 chars string.
+func encodeULID(id []byte) string {
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = ulidEncoding[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
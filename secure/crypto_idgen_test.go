@@ -0,0 +1,117 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/secure, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// Test ResolveNodeID, PodNodeID, IPNodeID.
+func TestResolveNodeID(t *testing.T) {
+	cases := []struct {
+		Case    string
+		Source  string
+		Want    int64
+		WantErr bool
+	}{
+		{"Resolve number node", "12", 12, false},
+		{"Resolve max node", "1023", 1023, false},
+		{"Resolve overflow node", "1024", 0, true},
+		{"Resolve invalid source", "node", 0, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			if node, err := ResolveNodeID(c.Source); (err != nil) != c.WantErr || node != c.Want {
+				t.Fatal("Resolve node:", node, "err:", err)
+			}
+		})
+	}
+
+	if node, _ := PodNodeID("acc-service-3"); node != 3 {
+		t.Fatal("Unmatched pod node:", node)
+	} else if _, err := PodNodeID("acc-service"); err == nil {
+		t.Fatal("Resolved pod node without ordinal!")
+	} else if node, _ := IPNodeID(net.ParseIP("192.168.1.37")); node != 293 {
+		t.Fatal("Unmatched ip node:", node)
+	}
+}
+
+// Test NewID, NewULID, NewUUIDv7 and decode them.
+func TestNewIDs(t *testing.T) {
+	cases := []struct {
+		Case   string
+		Format string
+		Decode func(id string) (time.Time, error)
+	}{
+		{"Snowflake IDs", IDSnowflake, func(id string) (time.Time, error) {
+			ts, _, err := DecodeSUID(id)
+			return ts, err
+		}},
+		{"ULID IDs", IDULID, DecodeULID},
+		{"UUIDv7 IDs", IDUUIDv7, DecodeUUIDv7},
+	}
+
+	defer SetIDFormat(IDSnowflake)
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			if err := SetIDFormat(c.Format); err != nil {
+				t.Fatal("Set id format, err:", err)
+			}
+
+			start, last := time.Now().Add(-time.Millisecond), ""
+			for i := 0; i < 10000; i++ {
+				id := NewID()
+				if c.Format != IDSnowflake && id <= last {
+					t.Fatal("Not monotonic ids:", last, id)
+				}
+				last = id
+			}
+
+			if ts, err := c.Decode(last); err != nil {
+				t.Fatal("Decode id, err:", err)
+			} else if ts.Before(start) || ts.After(time.Now().Add(time.Second)) {
+				t.Fatal("Unmatched id time:", ts)
+			}
+			t.Log(c.Case, "last id:", last)
+		})
+	}
+
+	SetNodeID(42)
+	defer SetNodeID(1)
+	if _, node, step := DecodeSnowflake(NewUID()); node != 42 || step < 0 {
+		t.Fatal("Unmatched snowflake node:", node)
+	}
+}
+
+// Test SetupIDGenerator return error of invalid node env.
+func TestSetupIDGenerator(t *testing.T) {
+	t.Setenv(idEnvNode, "acc-service")
+	if err := SetupIDGenerator(); err == nil {
+		t.Fatal("Setup invalid node without error!")
+	}
+
+	t.Setenv(idEnvNode, "7")
+	defer SetNodeID(1)
+	if err := SetupIDGenerator(); err != nil {
+		t.Fatal("Setup node, err:", err)
+	} else if _, node, _ := DecodeSnowflake(NewUID()); node != 7 {
+		t.Fatal("Unmatched snowflake node:", node)
+	}
+}
@@ -0,0 +1,429 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Typed jwt claims with custom data and registered claims.
+type JwtClaims[T any] struct {
+	Data T `json:"data,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Asymmetric jwt signing key with key id, the public key published
+// as JWK for verifiers.
+type JwtKey struct {
+	ID     string            // Key id output as 'kid' of jwt header
+	Method jwt.SigningMethod // One of RS256, ES256, ES384, ES512, EdDSA
+	Signer crypto.Signer     // Private key of *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey
+}
+
+// JSON Web Key Set document, see RFC 7517.
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// JSON Web Key of RSA, EC and OKP (Ed25519) public keys.
+type JWK struct {
+	Kty string `json:"kty"`           // Key type: RSA, EC, OKP
+	Kid string `json:"kid,omitempty"` // Key id
+	Use string `json:"use,omitempty"` // Key usage: sig
+	Alg string `json:"alg,omitempty"` // Algorithm: RS256, ES256, EdDSA
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // Curve: P-256, P-384, P-521, Ed25519
+	X   string `json:"x,omitempty"`   // EC or OKP x coordinate
+	Y   string `json:"y,omitempty"`   // EC y coordinate
+}
+
+// Jwt issuer to sign tokens by active key, and publish all unretired
+// public keys as JWKS to support keys rotation.
+//
+// # USAGE:
+//
+//	key, _ := secure.RSAJwtKey("2026-10", prikey)
+//	issuer := secure.NewJwtIssuer("accounts", key, "admin", "mall")
+//	token, _ := secure.IssueJwt(issuer, uid, &types.Profile{Role: "admin"}, time.Hour)
+//
+//	// publish JWKS on http server for verifiers.
+//	beego.Handler("/.well-known/jwks.json", issuer)
+type JwtIssuer struct {
+	mutex    sync.RWMutex
+	keys     []*JwtKey // All published keys, the last one is active
+	issuer   string
+	audience []string
+}
+
+// Jwt verifier to verify tokens signed by issuer, the public keys can
+// be added directly or loaded from JWKS url of issuer.
+//
+// # USAGE:
+//
+//	verifier := secure.NewJwtVerifier("accounts", "admin", time.Minute)
+//	verifier.LoadJWKS("https://accounts.wengold.net/.well-known/jwks.json")
+//	claims, err := secure.VerifyJwt[types.Profile](verifier, token)
+type JwtVerifier struct {
+	mutex    sync.RWMutex
+	keys     map[string]*JWK // Public keys mapping by key id
+	issuer   string
+	audience string
+	skew     time.Duration // Allowed clock skew for exp, nbf, iat
+	jwksurl  string        // JWKS url to reload keys for unknown kid
+	loadtime time.Time     // Last time loaded JWKS
+}
+
+// Min interval to reload JWKS for unknown key id.
+const jwksReloadInterval = time.Minute
+
+/* ------------------------------------------------------------------- */
+/* For Jwt Keys                                                        */
+/* ------------------------------------------------------------------- */
+
+// Create jwt key with private key, the signing method decided by key type
+// as RS256 for RSA, ES256/ES384/ES512 for ECDSA curves, EdDSA for Ed25519.
+func NewJwtKey(kid string, signer crypto.Signer) (*JwtKey, error) {
+	var method jwt.SigningMethod
+	switch key := signer.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			method = jwt.SigningMethodES256
+		case elliptic.P384():
+			method = jwt.SigningMethodES384
+		case elliptic.P521():
+			method = jwt.SigningMethodES512
+		default:
+			return nil, invar.ErrBadPriKey
+		}
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, invar.ErrBadPriKey
+	}
+	return &JwtKey{ID: kid, Method: method, Signer: signer}, nil
+}
+
+// Create RS256 jwt key from RSA private key pem string, see ParsePriKey().
+func RSAJwtKey(kid, prikey string, pkcs8 ...bool) (*JwtKey, error) {
+	key, err := ParsePriKey(prikey, pkcs8...)
+	if err != nil {
+		return nil, err
+	}
+	return NewJwtKey(kid, key)
+}
+
+// Create ES256 jwt key from ECC private key pem string, see EccPriKey().
+func EccJwtKey(kid, pripem string) (*JwtKey, error) {
+	key, err := EccPriKey(pripem)
+	if err != nil {
+		return nil, err
+	}
+	return NewJwtKey(kid, key)
+}
+
+// Return the public key as JWK.
+func (k *JwtKey) JWK() *JWK {
+	jwk := &JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64url(pub.N.Bytes())
+		jwk.E = b64url(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty, jwk.Crv = "EC", pub.Curve.Params().Name
+		jwk.X, jwk.Y = b64url(pub.X.FillBytes(make([]byte, size))), b64url(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64url(pub)
+	}
+	return jwk
+}
+
+// Return the public key of JWK.
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64urlDecode(k.N)
+		e, err2 := b64urlDecode(k.E)
+		if err1 != nil || err2 != nil || len(n) == 0 || len(e) == 0 {
+			return nil, invar.ErrBadPubKey
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, invar.ErrBadPubKey
+		}
+
+		x, err1 := b64urlDecode(k.X)
+		y, err2 := b64urlDecode(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil, invar.ErrBadPubKey
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, invar.ErrBadPubKey
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64urlDecode(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, invar.ErrBadPubKey
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, invar.ErrBadPubKey
+}
+
+/* ------------------------------------------------------------------- */
+/* For Jwt Issuer                                                      */
+/* ------------------------------------------------------------------- */
+
+// Create jwt issuer with active signing key and audiences.
+func NewJwtIssuer(issuer string, key *JwtKey, audience ...string) *JwtIssuer {
+	return &JwtIssuer{keys: []*JwtKey{key}, issuer: issuer, audience: audience}
+}
+
+// Rotate signing key, the new key used to sign tokens, and the old keys
+// still published in JWKS until retired.
+func (i *JwtIssuer) Rotate(key *JwtKey) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.retire(key.ID)
+	i.keys = append(i.keys, key)
+}
+
+// Retire the old key from JWKS after the tokens signed by it all expired,
+// it can not retire the active key.
+func (i *JwtIssuer) Retire(kid string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if active := i.keys[len(i.keys)-1]; active.ID != kid {
+		i.retire(kid)
+	}
+}
+
+// Return JWKS document of all published public keys.
+func (i *JwtIssuer) JWKS() *JWKS {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	jwks := &JWKS{Keys: make([]*JWK, 0, len(i.keys))}
+	for _, key := range i.keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	return jwks
+}
+
+// Serve JWKS document as http handler.
+func (i *JwtIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(i.JWKS())
+}
+
+// Remove the key of kid, call it in locking.
+func (i *JwtIssuer) retire(kid string) {
+	for idx, key := range i.keys {
+		if key.ID == kid {
+			i.keys = append(i.keys[:idx], i.keys[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Sign a jwt token by active key of issuer with subject and typed custom
+// data, it set kid header and iss, aud, iat, nbf, exp claims.
+func IssueJwt[T any](i *JwtIssuer, sub string, data T, ttl time.Duration) (string, error) {
+	i.mutex.RLock()
+	key := i.keys[len(i.keys)-1]
+	i.mutex.RUnlock()
+
+	now := time.Now()
+	claims := &JwtClaims[T]{
+		Data: data,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.issuer,
+			Subject:   sub,
+			Audience:  i.audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Signer)
+}
+
+/* ------------------------------------------------------------------- */
+/* For Jwt Verifier                                                    */
+/* ------------------------------------------------------------------- */
+
+// Create jwt verifier to check issuer, audience and time claims with the
+// allowed clock skew, set empty issuer or audience to skip check.
+func NewJwtVerifier(issuer, audience string, skew time.Duration) *JwtVerifier {
+	return &JwtVerifier{keys: make(map[string]*JWK), issuer: issuer, audience: audience, skew: skew}
+}
+
+// Add public key as JWK for verify tokens signed by the key of kid.
+func (v *JwtVerifier) AddKey(key *JWK) error {
+	if _, err := key.PublicKey(); err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.keys[key.Kid] = key
+	return nil
+}
+
+// Replace all public keys by the JWKS document.
+func (v *JwtVerifier) SetJWKS(jwks *JWKS) error {
+	keys := make(map[string]*JWK)
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		} else if _, err := key.PublicKey(); err != nil {
+			return err
+		}
+		keys[key.Kid] = key
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.keys = keys
+	return nil
+}
+
+// Load public keys from JWKS url, and reload it when verify token with an
+// unknown key id, so the issuer can rotate keys without redeploy verifiers.
+func (v *JwtVerifier) LoadJWKS(url string) error {
+	v.mutex.Lock()
+	v.jwksurl, v.loadtime = url, time.Now()
+	v.mutex.Unlock()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return invar.ErrInvalidState
+	}
+
+	jwks := &JWKS{}
+	if err := json.NewDecoder(resp.Body).Decode(jwks); err != nil {
+		return err
+	}
+	return v.SetJWKS(jwks)
+}
+
+// Verify jwt token signature by public key of kid, and check the claims of
+// exp, nbf, iat with clock skew, and iss, aud when verifier set them.
+//
+//	@Return invar.ErrTokenExpired: The token expired.
+//	@Return invar.ErrInvalidToken: Bad token, unknown kid, or invalid claims.
+func VerifyJwt[T any](v *JwtVerifier, signedToken string) (*JwtClaims[T], error) {
+	claims := &JwtClaims[T]{}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation(),
+		jwt.WithValidMethods([]string{"RS256", "ES256", "ES384", "ES512", "EdDSA"}))
+
+	_, err := parser.ParseWithClaims(signedToken, claims, v.keyfunc)
+	if err != nil {
+		return nil, invar.ErrInvalidToken
+	}
+
+	now := time.Now()
+	c := &claims.RegisteredClaims
+	if c.ExpiresAt == nil || !c.VerifyExpiresAt(now.Add(-v.skew), true) {
+		return nil, invar.ErrTokenExpired
+	} else if !c.VerifyNotBefore(now.Add(v.skew), false) || !c.VerifyIssuedAt(now.Add(v.skew), false) {
+		return nil, invar.ErrInvalidToken
+	} else if v.issuer != "" && !c.VerifyIssuer(v.issuer, true) {
+		return nil, invar.ErrInvalidToken
+	} else if v.audience != "" && !c.VerifyAudience(v.audience, true) {
+		return nil, invar.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Return the public key of token kid, it reload JWKS once for unknown kid.
+func (v *JwtVerifier) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.getKey(kid)
+	if !ok {
+		v.mutex.RLock()
+		url, reload := v.jwksurl, time.Since(v.loadtime) > jwksReloadInterval
+		v.mutex.RUnlock()
+
+		if url == "" || !reload {
+			return nil, invar.ErrInvalidToken
+		} else if err := v.LoadJWKS(url); err != nil {
+			return nil, err
+		} else if key, ok = v.getKey(kid); !ok {
+			return nil, invar.ErrInvalidToken
+		}
+	}
+
+	if key.Alg != "" && key.Alg != token.Method.Alg() {
+		return nil, errors.New("unmatched key algorithm")
+	}
+	return key.PublicKey()
+}
+
+// Return the public key of kid.
+func (v *JwtVerifier) getKey(kid string) (*JWK, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// Encode datas as base64 url string without padding.
+func b64url(src []byte) string {
+	return base64.RawURLEncoding.EncodeToString(src)
+}
+
+// Decode base64 url string without padding.
+func b64urlDecode(src string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(src)
+}
@@ -11,9 +11,15 @@
 package secure
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/wengoldx/xcore/invar"
 )
 
 // -------------------------------------------------------------------
@@ -56,3 +62,74 @@ func TestViaJwtToken(t *testing.T) {
 		})
 	}
 }
+
+// Test IssueJwt, VerifyJwt with RS256, ES256, EdDSA keys and rotation.
+func TestAsymmetricJwt(t *testing.T) {
+	type profile struct {
+		Role string `json:"role"`
+	}
+
+	rsakey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecckey, _ := NewEccPriKey()
+	_, edkey, _ := ed25519.GenerateKey(rand.Reader)
+	signers := []struct {
+		Case   string
+		Kid    string
+		Signer crypto.Signer
+	}{
+		{"RS256", "rsa-1", rsakey},
+		{"ES256", "ecc-1", ecckey},
+		{"EdDSA", "ed-1", edkey},
+	}
+
+	var issuer *JwtIssuer
+	verifier := NewJwtVerifier("accounts", "admin", time.Second)
+	for _, c := range signers {
+		t.Run(c.Case, func(t *testing.T) {
+			key, err := NewJwtKey(c.Kid, c.Signer)
+			if err != nil {
+				t.Fatal("New jwt key, err:", err)
+			}
+
+			if issuer == nil {
+				issuer = NewJwtIssuer("accounts", key, "admin")
+			} else {
+				issuer.Rotate(key)
+			}
+			if err := verifier.SetJWKS(issuer.JWKS()); err != nil {
+				t.Fatal("Set JWKS, err:", err)
+			}
+
+			token, err := IssueJwt(issuer, "12345678", &profile{Role: "admin"}, time.Minute)
+			if err != nil {
+				t.Fatal("Issue jwt, err:", err)
+			}
+
+			claims, err := VerifyJwt[profile](verifier, token)
+			if err != nil {
+				t.Fatal("Verify jwt, err:", err)
+			} else if claims.Subject != "12345678" || claims.Data.Role != "admin" {
+				t.Fatal("Invalid jwt claims:", claims)
+			}
+
+			other := NewJwtVerifier("accounts", "mall", time.Second)
+			other.SetJWKS(issuer.JWKS())
+			if _, err := VerifyJwt[profile](other, token); err != invar.ErrInvalidToken {
+				t.Fatal("Verified unmatched audience!")
+			}
+		})
+	}
+
+	if jwks := issuer.JWKS(); len(jwks.Keys) != 3 {
+		t.Fatal("Want 3 published keys, but:", len(jwks.Keys))
+	}
+	issuer.Retire("rsa-1")
+	if jwks := issuer.JWKS(); len(jwks.Keys) != 2 {
+		t.Fatal("Want 2 published keys after retired, but:", len(jwks.Keys))
+	}
+
+	expired, _ := IssueJwt(issuer, "12345678", &profile{}, -time.Minute)
+	if _, err := VerifyJwt[profile](verifier, expired); err != invar.ErrTokenExpired {
+		t.Fatal("Want expired error, but:", err)
+	}
+}
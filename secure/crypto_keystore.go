@@ -0,0 +1,571 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Private key with the leaf certificate and chain certificates loaded from
+// PKCS#12 bundle, or PEM certificates and private key.
+//
+// # USAGE:
+//
+//	// load wechat pay merchant cert bundle.
+//	ks, _ := secure.LoadPKCS12File("apiclient_cert.p12", mchid)
+//	prikey, _ := ks.RSAKey()
+//	serial := ks.Serial()
+//
+//	// load grpc tls cert chain and passphrase protected key.
+//	ks, _ := secure.LoadKeyStore(certpem, keypem, passphrase)
+//	if err := ks.Verify(roots); err != nil {
+//		logger.E("Invalid cert chain, err:", err)
+//	}
+type KeyStore struct {
+	PrivateKey crypto.PrivateKey   // Private key, maybe nil when only certs loaded
+	Leaf       *x509.Certificate   // Leaf certificate matched with private key
+	Chain      []*x509.Certificate // Intermediate and root certificates, not contain leaf
+}
+
+// Certificate expiry report item.
+type CertExpiry struct {
+	Name     string    // Name of keystore or cert, set by caller
+	Subject  string    // Certificate subject common name
+	Serial   string    // Certificate serial number as upper hex string
+	NotAfter time.Time // Certificate expire time
+	DaysLeft int       // Days left to expire, negative for expired
+}
+
+// Encrypted PKCS#8 private key pem file header.
+const PKCS8_PEM_ENC_HEADER = "ENCRYPTED PRIVATE KEY"
+
+// Object identifiers of PKCS#5 v2.0 password based encryption.
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHmacSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHmacSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHmacSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// PKCS#8 EncryptedPrivateKeyInfo structure.
+type encryptedPKInfo struct {
+	Algo pkix.AlgorithmIdentifier
+	Data []byte
+}
+
+// PBES2 parameters structure.
+type pbes2Params struct {
+	KDF    pkix.AlgorithmIdentifier
+	Scheme pkix.AlgorithmIdentifier
+}
+
+// PBKDF2 parameters structure.
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+/* ------------------------------------------------------------------- */
+/* For KeyStore Loaders                                                */
+/* ------------------------------------------------------------------- */
+
+// Load keystore from PKCS#12 bundle datas, it support the legacy 3DES and
+// RC2 encrypted bundles such as wechat pay merchant certs.
+//
+// # WARNING:
+//   - The bundles encrypted by AES (PBES2, default of OpenSSL 3.x) not supported,
+//     please export it with '-legacy' option, or convert to pem files.
+func LoadPKCS12(data []byte, password string) (*KeyStore, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ks, certs := &KeyStore{}, []*x509.Certificate{}
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		default:
+			if ks.PrivateKey, err = parsePriKeyDER(block.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ks, ks.setCerts(certs)
+}
+
+// Load keystore from PKCS#12 bundle file.
+func LoadPKCS12File(p12file, password string) (*KeyStore, error) {
+	data, err := os.ReadFile(p12file)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPKCS12(data, password)
+}
+
+// Load keystore from PEM certificates chain and private key, the private key
+// may be PKCS#1, SEC1, PKCS#8 format, or encrypted by the given passphrase.
+//
+// Set keypem as empty string to only load the certificates chain, and the
+// first certificate used as leaf.
+func LoadKeyStore(certpem, keypem string, passphrase ...string) (*KeyStore, error) {
+	certs, err := ParseCertChain(certpem)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeyStore{}
+	if keypem != "" {
+		if ks.PrivateKey, err = ParseKeyPem(keypem, passphrase...); err != nil {
+			return nil, err
+		}
+	}
+	return ks, ks.setCerts(certs)
+}
+
+// Load keystore from PEM certificates chain file and private key file.
+func LoadKeyStoreFiles(certfile, keyfile string, passphrase ...string) (*KeyStore, error) {
+	certpem, err := os.ReadFile(certfile)
+	if err != nil {
+		return nil, err
+	}
+
+	keypem := []byte{}
+	if keyfile != "" {
+		if keypem, err = os.ReadFile(keyfile); err != nil {
+			return nil, err
+		}
+	}
+	return LoadKeyStore(string(certpem), string(keypem), passphrase...)
+}
+
+// Parse all certificates from multiple PEM blocks, the other blocks ignored.
+func ParseCertChain(certpem string) ([]*x509.Certificate, error) {
+	certs, rest := []*x509.Certificate{}, []byte(certpem)
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		} else if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, invar.ErrInvalidData
+	}
+	return certs, nil
+}
+
+// Create certificates pool from PEM certificates, it return error when
+// any pem data not contain certificate.
+func NewCertPool(certpems ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, certpem := range certpems {
+		if !pool.AppendCertsFromPEM([]byte(certpem)) {
+			return nil, invar.ErrInvalidData
+		}
+	}
+	return pool, nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Private Key Utils                                               */
+/* ------------------------------------------------------------------- */
+
+// Parse RSA, ECDSA, Ed25519 private key from pem data whatever PKCS#1,
+// SEC1, PKCS#8 format, and the PKCS#8 (PBES2) or legacy PEM encrypted
+// private key decrypt by passphrase.
+//
+//	prikey, _ := secure.ParseKeyPem(keypem, "passphrase")
+//	rsakey, ok := prikey.(*rsa.PrivateKey)
+func ParseKeyPem(keypem string, passphrase ...string) (crypto.PrivateKey, error) {
+	block, rest := pem.Decode([]byte(keypem))
+	for block != nil && !isKeyBlock(block.Type) {
+		block, rest = pem.Decode(rest)
+	}
+	if block == nil {
+		return nil, invar.ErrBadPriKey
+	}
+
+	password := ""
+	if len(passphrase) > 0 {
+		password = passphrase[0]
+	}
+
+	der := block.Bytes
+	if block.Type == PKCS8_PEM_ENC_HEADER {
+		if password == "" {
+			return nil, invar.ErrInvalidParams
+		}
+		return decryptPKCS8(der, []byte(password))
+	} else if x509.IsEncryptedPEMBlock(block) { //lint:ignore SA1019 legacy encrypted pem
+		if password == "" {
+			return nil, invar.ErrInvalidParams
+		}
+
+		var err error
+		if der, err = x509.DecryptPEMBlock(block, []byte(password)); err != nil { //lint:ignore SA1019 legacy encrypted pem
+			return nil, err
+		}
+	}
+	return parsePriKeyDER(der)
+}
+
+// Parse private key from pem file, see ParseKeyPem().
+func ParseKeyPem4F(keyfile string, passphrase ...string) (crypto.PrivateKey, error) {
+	keypem, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyPem(string(keypem), passphrase...)
+}
+
+// Encrypt private key to PKCS#8 pem string by passphrase, it use PBKDF2
+// with HMAC-SHA256 and AES-256-CBC as OpenSSL default.
+func EncryptKeyPem(prikey crypto.PrivateKey, passphrase string) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(prikey)
+	if err != nil {
+		return "", err
+	} else if passphrase == "" {
+		return "", invar.ErrInvalidParams
+	}
+
+	salt, iv := make([]byte, 16), make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	} else if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+
+	iterations := 100000
+	key := pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New)
+	block, _ := aes.NewCipher(key)
+	ciphertext := pkcs5Padding(der, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	kdf, _ := asn1.Marshal(pbkdf2Params{Salt: salt, Iterations: iterations,
+		PRF: pkix.AlgorithmIdentifier{Algorithm: oidHmacSHA256, Parameters: asn1.NullRawValue}})
+	ivparam, _ := asn1.Marshal(iv)
+	params, _ := asn1.Marshal(pbes2Params{
+		KDF:    pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		Scheme: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivparam}},
+	})
+
+	info, err := asn1.Marshal(encryptedPKInfo{
+		Algo: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		Data: ciphertext,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: PKCS8_PEM_ENC_HEADER, Bytes: info})), nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For KeyStore Methods                                                */
+/* ------------------------------------------------------------------- */
+
+// Return the RSA private key, or error when not RSA key.
+func (ks *KeyStore) RSAKey() (*rsa.PrivateKey, error) {
+	if prikey, ok := ks.PrivateKey.(*rsa.PrivateKey); ok {
+		return prikey, nil
+	}
+	return nil, invar.ErrBadPriKey
+}
+
+// Return the leaf certificate serial number as upper hex string.
+func (ks *KeyStore) Serial() string {
+	if ks.Leaf == nil {
+		return ""
+	}
+	return certSerial(ks.Leaf)
+}
+
+// Return the tls certificate for tls server or client configs.
+//
+//	cfg := &tls.Config{Certificates: []tls.Certificate{ks.TLSCertificate()}}
+func (ks *KeyStore) TLSCertificate() tls.Certificate {
+	cert := tls.Certificate{PrivateKey: ks.PrivateKey, Leaf: ks.Leaf}
+	if ks.Leaf != nil {
+		cert.Certificate = append(cert.Certificate, ks.Leaf.Raw)
+	}
+	for _, c := range ks.Chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert
+}
+
+// Verify leaf certificate chain by the given roots pool, the chain certs
+// used as intermediates, and verify at current time when not set at param.
+//
+// Set roots as nil to use the system roots pool.
+func (ks *KeyStore) Verify(roots *x509.CertPool, at ...time.Time) error {
+	if ks.Leaf == nil {
+		return invar.ErrNotFound
+	}
+
+	opts := x509.VerifyOptions{
+		Roots: roots, Intermediates: x509.NewCertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if len(at) > 0 {
+		opts.CurrentTime = at[0]
+	}
+	for _, c := range ks.Chain {
+		opts.Intermediates.AddCert(c)
+	}
+
+	_, err := ks.Leaf.Verify(opts)
+	return err
+}
+
+// Return leaf and chain certificates expiring within the given days, the
+// expired certificates contained too.
+func (ks *KeyStore) Expiring(name string, days int) []*CertExpiry {
+	certs := ks.Chain
+	if ks.Leaf != nil {
+		certs = append([]*x509.Certificate{ks.Leaf}, ks.Chain...)
+	}
+	return ExpiringCerts(name, days, certs...)
+}
+
+// Return certificates expiring within the given days sorted by expire time,
+// it useful to report certificates alert periodically.
+//
+//	for _, exp := range secure.ExpiringCerts("wxpay", 30, certs...) {
+//		logger.W("Cert", exp.Name, exp.Subject, "expire in", exp.DaysLeft, "days")
+//	}
+func ExpiringCerts(name string, days int, certs ...*x509.Certificate) []*CertExpiry {
+	now, expiries := time.Now(), []*CertExpiry{}
+	deadline := now.AddDate(0, 0, days)
+	for _, cert := range certs {
+		if cert.NotAfter.After(deadline) {
+			continue
+		}
+
+		left := int(cert.NotAfter.Sub(now).Hours() / 24)
+		expiries = append(expiries, &CertExpiry{
+			Name: name, Subject: cert.Subject.CommonName, Serial: certSerial(cert),
+			NotAfter: cert.NotAfter, DaysLeft: left,
+		})
+	}
+
+	sort.Slice(expiries, func(i, j int) bool {
+		return expiries[i].NotAfter.Before(expiries[j].NotAfter)
+	})
+	return expiries
+}
+
+/* ------------------------------------------------------------------- */
+/* Private methods define.                                             */
+/* ------------------------------------------------------------------- */
+
+// Set the leaf certificate matched with private key, and others as chain.
+func (ks *KeyStore) setCerts(certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return invar.ErrNotFound
+	}
+
+	leaf := 0
+	if ks.PrivateKey != nil {
+		leaf = -1
+		for i, cert := range certs {
+			if matchPubKey(ks.PrivateKey, cert.PublicKey) {
+				leaf = i
+				break
+			}
+		}
+		if leaf < 0 {
+			return invar.ErrBadPubKey
+		}
+	}
+
+	ks.Leaf = certs[leaf]
+	ks.Chain = append(append([]*x509.Certificate{}, certs[:leaf]...), certs[leaf+1:]...)
+	return nil
+}
+
+// Check whether the private key pair with public key.
+func matchPubKey(prikey crypto.PrivateKey, pubkey crypto.PublicKey) bool {
+	signer, ok := prikey.(crypto.Signer)
+	if !ok {
+		return false
+	}
+
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(pubkey)
+}
+
+// Parse private key DER data whatever PKCS#1, SEC1, PKCS#8 format.
+func parsePriKeyDER(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	} else if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	} else if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return key, nil
+		}
+	}
+	return nil, invar.ErrBadPriKey
+}
+
+// Check whether the pem block type is private key.
+func isKeyBlock(blocktype string) bool {
+	switch blocktype {
+	case PKCS8_PEM_PRI_HEADER, PKCS8_PEM_ENC_HEADER, RSA_PEM_PRI_HEADER, ECC_PEM_PRI_HEADER, "EC PRIVATE KEY":
+		return true
+	}
+	return false
+}
+
+// Decrypt PKCS#8 (PBES2) encrypted private key DER data.
+func decryptPKCS8(der, password []byte) (crypto.PrivateKey, error) {
+	info := encryptedPKInfo{}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	} else if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, invar.ErrNotSupport
+	}
+
+	params := pbes2Params{}
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &params); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	} else if !params.KDF.Algorithm.Equal(oidPBKDF2) {
+		return nil, invar.ErrNotSupport
+	}
+
+	kdf := pbkdf2Params{}
+	if _, err := asn1.Unmarshal(params.KDF.Parameters.FullBytes, &kdf); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	var prf func() hash.Hash
+	switch algo := kdf.PRF.Algorithm; {
+	case len(algo) == 0 || algo.Equal(oidHmacSHA1):
+		prf = sha1.New
+	case algo.Equal(oidHmacSHA256):
+		prf = sha256.New
+	case algo.Equal(oidHmacSHA512):
+		prf = sha512.New
+	default:
+		return nil, invar.ErrNotSupport
+	}
+
+	var keylen int
+	var newcipher func(key []byte) (cipher.Block, error)
+	switch algo := params.Scheme.Algorithm; {
+	case algo.Equal(oidAES128CBC):
+		keylen, newcipher = 16, aes.NewCipher
+	case algo.Equal(oidAES192CBC):
+		keylen, newcipher = 24, aes.NewCipher
+	case algo.Equal(oidAES256CBC):
+		keylen, newcipher = 32, aes.NewCipher
+	case algo.Equal(oidDESEDE3CBC):
+		keylen, newcipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, invar.ErrNotSupport
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.Scheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	block, err := newcipher(pbkdf2.Key(password, kdf.Salt, kdf.Iterations, keylen, prf))
+	if err != nil {
+		return nil, err
+	} else if len(iv) != block.BlockSize() || len(info.Data)%block.BlockSize() != 0 {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	plain := make([]byte, len(info.Data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.Data)
+	if plain, err = pkcs7Unpad(plain, block.BlockSize()); err != nil {
+		return nil, invar.ErrInvalidParams // wrong passphrase mostly
+	}
+
+	key, err := parsePriKeyDER(plain)
+	if err != nil {
+		return nil, invar.ErrInvalidParams
+	}
+	return key, nil
+}
+
+// Remove PKCS#7 padding of datas, it check padding bytes than pkcs5Unpadding().
+func pkcs7Unpad(data []byte, size int) ([]byte, error) {
+	length := len(data)
+	if length == 0 || length%size != 0 {
+		return nil, invar.ErrInvalidData
+	}
+
+	padding := int(data[length-1])
+	if padding == 0 || padding > size {
+		return nil, invar.ErrInvalidData
+	}
+	for _, b := range data[length-padding:] {
+		if int(b) != padding {
+			return nil, invar.ErrInvalidData
+		}
+	}
+	return data[:length-padding], nil
+}
+
+// Return certificate serial number as upper hex string.
+func certSerial(cert *x509.Certificate) string {
+	return strings.ToUpper(hex.EncodeToString(cert.SerialNumber.Bytes()))
+}
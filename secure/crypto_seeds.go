@@ -86,6 +86,10 @@ func SeedRSign(pr string, ts ...string) (string, error) { return _def_signer.Rsa
 func SeedRVerify(s, pu string, ts ...string) (bool, error) {
 	return _def_signer.RsaVerify(s, pu, ts...)
 }
+func SeedDSign(pr string, ts ...string) (string, error) { return _def_signer.Ed25519Sign(pr, ts...) }
+func SeedDVerify(s, pu string, ts ...string) (bool, error) {
+	return _def_signer.Ed25519Verify(s, pu, ts...)
+}
 
 // Utils Methods End <<
 
@@ -181,6 +185,14 @@ func (s *SeedSign) signSeedNum(sign string) (string, string) {
 //	// verify by secure.RSAVerify(pubkey, plaintext, signbytes)
 //	// Or, call secure.SeedRSign() and secure.SeedRVerify().
 //
+// 3. Generate Ed25519 signature.
+//
+//	pri, pub, _ := secure.NewEd25519Keys()
+//	plaintext := secure.SignPlaintext(data, data1, ...)
+//	prikey, _ := secure.Ed25519PriKey(pri)
+//	sign, _ := secure.Ed25519Sign(plaintext, prikey)
+//	// Or, call secure.SeedDSign() and secure.SeedDVerify().
+//
 // Then call secure.DefSeedSign().ViaCode() to verify sign and code whether matched.
 //
 // # WARING:
@@ -310,3 +322,31 @@ func (s *SeedSign) RsaVerify(sign, pubkey string, texts ...string) (bool, error)
 	}
 	return false, invar.ErrEmptyData
 }
+
+// Use Ed25519 private key pem content to sign plaintext as sign string (formated as base64 string).
+//
+// # WARNING:
+//   - Use the same plaintext and Ed25519 private key to sign, it always
+//     output the same sign strings.
+func (s *SeedSign) Ed25519Sign(pripem string, texts ...string) (string, error) {
+	if plaintext := s.SignPlaintext(texts...); plaintext != "" {
+		prikey, err := Ed25519PriKey(pripem)
+		if err != nil {
+			return "", err
+		}
+		return Ed25519Sign(plaintext, prikey)
+	}
+	return "", invar.ErrEmptyData
+}
+
+// Use Ed25519 public key pem content to verify plaintext and base64 sign string.
+func (s *SeedSign) Ed25519Verify(sign, pubpem string, texts ...string) (bool, error) {
+	if plaintext := s.SignPlaintext(texts...); plaintext != "" {
+		pubkey, err := Ed25519PubKey(pubpem)
+		if err != nil {
+			return false, err
+		}
+		return Ed25519Verify(plaintext, sign, pubkey)
+	}
+	return false, invar.ErrEmptyData
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/wengoldx/xcore/invar"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Streaming encryption header format (version 1), all integers are big endian.
+//
+//	magic 'XSE' (3) | version (1) | mode (1) | chunk size (4) | salt (16) | key length (2) | wrapped key (n)
+//
+// The contents split into chunks and sealed by AES-256-GCM with the file key
+// derived by HKDF-SHA256 from content key and salt, each chunk nonce is the
+// 11 bytes chunk counter and 1 byte final flag, the header authenticated as
+// additional data of every chunk, so any truncation, reorder or header
+// tampering will failed to decrypt.
+const (
+	streamMagic     = "XSE"
+	streamVersion   = 1
+	streamSaltSize  = 16
+	streamKeySize   = 32
+	streamTagSize   = 16
+	streamNonceSize = 12
+	streamInfo      = "xcore-stream-v1"
+
+	// Default plaintext chunk size of streaming encryption.
+	StreamChunkSize = 64 * 1024
+)
+
+// Content key wrap modes of streaming encryption.
+const (
+	StreamModeKey    byte = 0 // Symmetric key, no wrapped key
+	StreamModeRSA    byte = 1 // Random content key wrapped by RSAEncrypt()
+	StreamModeX25519 byte = 2 // Content key agreed by ephemeral X25519 ECDH
+	StreamModeP256   byte = 3 // Content key agreed by ephemeral P-256 ECDH
+)
+
+// Stream encrypt writer to seal chunks and write to target writer.
+type streamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	size    int
+	counter uint64
+	closed  bool
+}
+
+// Stream decrypt reader to open chunks from source reader.
+type streamReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	chunk   []byte // Sealed chunk buffer with 1 byte look-ahead
+	pending int    // Look-ahead bytes remain in chunk buffer
+	plain   []byte // Decrypted datas not read
+	counter uint64
+	final   bool
+}
+
+/* ------------------------------------------------------------------- */
+/* For Stream Encrypt Writers                                          */
+/* ------------------------------------------------------------------- */
+
+// Create stream encrypt writer with 32 bytes symmetric key, the Close()
+// must be called to write the final chunk.
+//
+//	dst, _ := os.Create("merged.bin.enc")
+//	w, _ := secure.NewEncryptWriter(dst, key)
+//	io.Copy(w, src)
+//	w.Close() // write final chunk, it not close dst file.
+func NewEncryptWriter(w io.Writer, key []byte, chunksize ...int) (io.WriteCloser, error) {
+	if len(key) != streamKeySize {
+		return nil, invar.ErrInvalidParams
+	}
+	return newStreamWriter(w, StreamModeKey, key, nil, chunksize...)
+}
+
+// Create stream encrypt writer with a random content key wrapped by RSA
+// public key pem string, only the private key holder can decrypt it.
+func NewRSAEncryptWriter(w io.Writer, pubkey string, chunksize ...int) (io.WriteCloser, error) {
+	key := make([]byte, streamKeySize)
+	if _, err := io.ReadFull(crand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := RSAEncrypt(pubkey, string(key))
+	if err != nil {
+		return nil, err
+	}
+	return newStreamWriter(w, StreamModeRSA, key, wrapped, chunksize...)
+}
+
+// Create stream encrypt writer with content key agreed by ephemeral ECDH
+// key and the recipient X25519 or P-256 public key.
+func NewECDHEncryptWriter(w io.Writer, pubkey *ecdh.PublicKey, chunksize ...int) (io.WriteCloser, error) {
+	mode, err := ecdhMode(pubkey.Curve())
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := pubkey.Curve().GenerateKey(crand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ephemeral.ECDH(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return newStreamWriter(w, mode, secret, ephemeral.PublicKey().Bytes(), chunksize...)
+}
+
+// Seal and write the full chunks, the last chunk kept until Close().
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, invar.ErrInvalidState
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// seal full chunk only when more datas coming.
+		if len(s.buf) == s.size {
+			if err := s.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := min(s.size-len(s.buf), len(p))
+		s.buf = append(s.buf, p[:n]...)
+		p, written = p[n:], written+n
+	}
+	return written, nil
+}
+
+// Seal and write the final chunk, it not close the target writer.
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.seal(true)
+}
+
+// Seal buffered plaintext as a chunk and write out.
+func (s *streamWriter) seal(final bool) error {
+	nonce := streamNonce(s.counter, final)
+	sealed := s.aead.Seal(nil, nonce, s.buf, s.header)
+	if _, err := s.w.Write(sealed); err != nil {
+		return err
+	}
+	s.buf, s.counter = s.buf[:0], s.counter+1
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Stream Decrypt Readers                                          */
+/* ------------------------------------------------------------------- */
+
+// Create stream decrypt reader with 32 bytes symmetric key.
+//
+//	src, _ := os.Open("merged.bin.enc")
+//	r, _ := secure.NewDecryptReader(src, key)
+//	io.Copy(dst, r) // return error when datas tampered or truncated.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	return newStreamReader(r, func(mode byte, _ []byte) ([]byte, error) {
+		if mode != StreamModeKey || len(key) != streamKeySize {
+			return nil, invar.ErrInvalidParams
+		}
+		return key, nil
+	})
+}
+
+// Create stream decrypt reader to unwrap content key by RSA private key pem string.
+func NewRSADecryptReader(r io.Reader, prikey string) (io.Reader, error) {
+	return newStreamReader(r, func(mode byte, wrapped []byte) ([]byte, error) {
+		if mode != StreamModeRSA {
+			return nil, invar.ErrInvalidParams
+		}
+		return RSADecrypt(prikey, wrapped)
+	})
+}
+
+// Create stream decrypt reader to agree content key by recipient X25519 or
+// P-256 private key and the ephemeral public key in header.
+func NewECDHDecryptReader(r io.Reader, prikey *ecdh.PrivateKey) (io.Reader, error) {
+	return newStreamReader(r, func(mode byte, wrapped []byte) ([]byte, error) {
+		if want, err := ecdhMode(prikey.Curve()); err != nil || want != mode {
+			return nil, invar.ErrInvalidParams
+		}
+
+		ephemeral, err := prikey.Curve().NewPublicKey(wrapped)
+		if err != nil {
+			return nil, invar.ErrBadPubKey
+		}
+		return prikey.ECDH(ephemeral)
+	})
+}
+
+// Read and decrypt datas, it return error when chunk authenticate failed,
+// or the stream truncated without final chunk.
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.plain) == 0 {
+		if s.final {
+			return 0, io.EOF
+		} else if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.plain)
+	s.plain = s.plain[n:]
+	return n, nil
+}
+
+// Read and open next chunk, it read 1 more byte to check the final chunk.
+func (s *streamReader) open() error {
+	n, err := io.ReadFull(s.r, s.chunk[s.pending:])
+	n += s.pending
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+
+	size, final := n, true
+	if n == len(s.chunk) {
+		size, final = n-1, false // keep the look-ahead byte for next chunk
+	}
+	if size < streamTagSize {
+		return io.ErrUnexpectedEOF
+	}
+
+	plain, err := s.aead.Open(nil, streamNonce(s.counter, final), s.chunk[:size], s.header)
+	if err != nil {
+		return invar.ErrInvalidData
+	}
+
+	if !final {
+		s.chunk[0], s.pending = s.chunk[size], 1
+	}
+	s.plain, s.final, s.counter = plain, final, s.counter+1
+	return nil
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Create stream writer and write out header.
+func newStreamWriter(w io.Writer, mode byte, key, wrapped []byte, chunksize ...int) (*streamWriter, error) {
+	size := StreamChunkSize
+	if len(chunksize) > 0 && chunksize[0] > 0 {
+		size = chunksize[0]
+	}
+
+	salt := make([]byte, streamSaltSize)
+	if _, err := io.ReadFull(crand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	header := &bytes.Buffer{}
+	header.WriteString(streamMagic)
+	header.WriteByte(streamVersion)
+	header.WriteByte(mode)
+	binary.Write(header, binary.BigEndian, uint32(size))
+	header.Write(salt)
+	binary.Write(header, binary.BigEndian, uint16(len(wrapped)))
+	header.Write(wrapped)
+
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	} else if _, err := w.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	return &streamWriter{w: w, aead: aead, header: header.Bytes(), size: size, buf: make([]byte, 0, size)}, nil
+}
+
+// Read header and create stream reader by the content key from keyfunc.
+func newStreamReader(r io.Reader, keyfunc func(mode byte, wrapped []byte) ([]byte, error)) (*streamReader, error) {
+	fixed := make([]byte, len(streamMagic)+2+4+streamSaltSize+2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	} else if string(fixed[:3]) != streamMagic || fixed[3] != streamVersion {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	mode, size := fixed[4], binary.BigEndian.Uint32(fixed[5:9])
+	salt, keylen := fixed[9:9+streamSaltSize], binary.BigEndian.Uint16(fixed[9+streamSaltSize:])
+	if size == 0 || size > 64*1024*1024 {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	wrapped := make([]byte, keylen)
+	if _, err := io.ReadFull(r, wrapped); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	key, err := keyfunc(mode, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append(fixed, wrapped...)
+	chunk := make([]byte, int(size)+streamTagSize+1)
+	return &streamReader{r: r, aead: aead, header: header, chunk: chunk}, nil
+}
+
+// Create AES-256-GCM with the file key derived from content key and salt.
+func streamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	filekey := make([]byte, streamKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(streamInfo)), filekey); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(filekey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Return chunk nonce of 11 bytes counter and 1 byte final flag.
+func streamNonce(counter uint64, final bool) []byte {
+	nonce := make([]byte, streamNonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// Return stream mode of ECDH curve.
+func ecdhMode(curve ecdh.Curve) (byte, error) {
+	switch curve {
+	case ecdh.X25519():
+		return StreamModeX25519, nil
+	case ecdh.P256():
+		return StreamModeP256, nil
+	}
+	return 0, invar.ErrNotSupport
+}
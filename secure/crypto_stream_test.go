@@ -0,0 +1,90 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package secure
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/secure, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+func TestStreamEncrypt(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	prikey, pubkey, _ := NewRSAKeys(2048)
+	xkey, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	pkey, _ := ecdh.P256().GenerateKey(rand.Reader)
+
+	type streamer struct {
+		Case    string
+		Encrypt func(w io.Writer) (io.WriteCloser, error)
+		Decrypt func(r io.Reader) (io.Reader, error)
+	}
+	streamers := []streamer{
+		{"Symmetric", func(w io.Writer) (io.WriteCloser, error) { return NewEncryptWriter(w, key, 1024) },
+			func(r io.Reader) (io.Reader, error) { return NewDecryptReader(r, key) }},
+		{"RSA Wrap ", func(w io.Writer) (io.WriteCloser, error) { return NewRSAEncryptWriter(w, pubkey, 1024) },
+			func(r io.Reader) (io.Reader, error) { return NewRSADecryptReader(r, prikey) }},
+		{"X25519   ", func(w io.Writer) (io.WriteCloser, error) { return NewECDHEncryptWriter(w, xkey.PublicKey(), 1024) },
+			func(r io.Reader) (io.Reader, error) { return NewECDHDecryptReader(r, xkey) }},
+		{"P-256    ", func(w io.Writer) (io.WriteCloser, error) { return NewECDHEncryptWriter(w, pkey.PublicKey(), 1024) },
+			func(r io.Reader) (io.Reader, error) { return NewECDHDecryptReader(r, pkey) }},
+	}
+
+	for _, s := range streamers {
+		for _, size := range []int{0, 100, 1024, 2048, 5000} {
+			original := make([]byte, size)
+			rand.Read(original)
+
+			sealed := &bytes.Buffer{}
+			w, err := s.Encrypt(sealed)
+			if err != nil {
+				t.Fatal(s.Case, "create encrypt writer, err:", err)
+			}
+			io.Copy(w, bytes.NewReader(original))
+			w.Close()
+			ciphertext := sealed.Bytes()
+
+			r, err := s.Decrypt(bytes.NewReader(ciphertext))
+			if err != nil {
+				t.Fatal(s.Case, "create decrypt reader, err:", err)
+			} else if plain, err := io.ReadAll(r); err != nil || !bytes.Equal(plain, original) {
+				t.Fatal(s.Case, "decrypt size:", size, "unmatched, err:", err)
+			}
+
+			// truncate the final chunk.
+			if size > 1024 {
+				r, _ := s.Decrypt(bytes.NewReader(ciphertext[:len(ciphertext)-200]))
+				if _, err := io.ReadAll(r); err == nil {
+					t.Fatal(s.Case, "decrypted truncated stream!")
+				}
+			}
+
+			// tamper the last byte.
+			tampered := bytes.Clone(ciphertext)
+			tampered[len(tampered)-1] ^= 0x01
+			if r, err := s.Decrypt(bytes.NewReader(tampered)); err == nil {
+				if _, err := io.ReadAll(r); err == nil {
+					t.Fatal(s.Case, "decrypted tampered stream!")
+				}
+			}
+		}
+		t.Log(s.Case, "passed")
+	}
+}
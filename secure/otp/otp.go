@@ -0,0 +1,276 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wengoldx/xcore/invar"
+)
+
+// Supported HMAC algorithms of one-time password.
+const (
+	SHA1   = "SHA1" // Default, supported by all authenticator apps
+	SHA256 = "SHA256"
+	SHA512 = "SHA512"
+)
+
+// One-time password options, the empty fields use default values.
+type Options struct {
+	Issuer    string // Issuer name show on authenticator apps
+	Digits    int    // Code digits, 6 (default) or 8
+	Period    int    // TOTP time step in seconds, default 30
+	Skew      int    // Allowed steps before and after current time, default 1, set -1 to disable
+	Algorithm string // One of SHA1 (default), SHA256, SHA512
+}
+
+// Store to record used codes for replay protection, it should return
+// false when the counter of key already used.
+type UsedStore interface {
+	// Mark the counter of key as used and expired after ttl, return false
+	// when it already used before.
+	Use(key string, counter uint64, ttl time.Duration) (bool, error)
+}
+
+// TOTP authenticator with options and optional used codes store.
+type TOTP struct {
+	opts  Options
+	store UsedStore
+}
+
+// Default secret length in bytes, as RFC 4226 recommended 160 bits.
+const secretBytes = 20
+
+// Base32 encoding without padding for secrets.
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Create a random base32 secret for one-time password, the default size
+// is 20 bytes, and it output 32 chars string.
+func NewSecret(size ...int) (string, error) {
+	length := secretBytes
+	if len(size) > 0 && size[0] > 0 {
+		length = size[0]
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(buf), nil
+}
+
+// Generate RFC 4226 HOTP code of counter, the opts only use Digits and
+// Algorithm fields.
+func HOTP(secret string, counter uint64, opts *Options) (string, error) {
+	o := fillOptions(opts)
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return generate(key, counter, o), nil
+}
+
+// Verify RFC 4226 HOTP code in the look-ahead window from counter, and
+// return the next counter to store when verified.
+//
+//	if next, ok := otp.VerifyHOTP(secret, code, account.Counter, 5, nil); ok {
+//		account.Counter = next // resynchronize counter
+//	}
+func VerifyHOTP(secret, code string, counter uint64, window int, opts *Options) (uint64, bool) {
+	o := fillOptions(opts)
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != o.Digits {
+		return counter, false
+	}
+
+	for i := 0; i <= window; i++ {
+		if equalCode(generate(key, counter+uint64(i), o), code) {
+			return counter + uint64(i) + 1, true
+		}
+	}
+	return counter, false
+}
+
+// Create TOTP authenticator, set store as nil to disable replay protection.
+//
+//	totp := otp.NewTOTP(&otp.Options{Issuer: "Wengold"}, otp.NewMemoryStore())
+//	uri := totp.URI(secret, "user@wengold.net") // show as QR code
+//	ok, err := totp.Verify(uid, secret, code)
+func NewTOTP(opts *Options, store UsedStore) *TOTP {
+	return &TOTP{opts: fillOptions(opts), store: store}
+}
+
+// Generate RFC 6238 TOTP code at the given time.
+func (t *TOTP) Generate(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return generate(key, t.counter(at), t.opts), nil
+}
+
+// Verify TOTP code of current time in the skew window, the verified code
+// marked as used by key (such as account uid) and can not use again.
+func (t *TOTP) Verify(key, secret, code string) (bool, error) {
+	return t.VerifyAt(key, secret, code, time.Now())
+}
+
+// Verify TOTP code at the given time, see Verify().
+func (t *TOTP) VerifyAt(key, secret, code string, at time.Time) (bool, error) {
+	k, err := decodeSecret(secret)
+	if err != nil {
+		return false, err
+	} else if len(code) != t.opts.Digits {
+		return false, nil
+	}
+
+	current := t.counter(at)
+	for i := -t.opts.Skew; i <= t.opts.Skew; i++ {
+		counter := current + uint64(i)
+		if i < 0 && current < uint64(-i) {
+			continue
+		}
+
+		if equalCode(generate(k, counter, t.opts), code) {
+			if t.store == nil {
+				return true, nil
+			}
+
+			ttl := time.Duration((2*t.opts.Skew+1)*t.opts.Period) * time.Second
+			return t.store.Use(key, counter, ttl)
+		}
+	}
+	return false, nil
+}
+
+// Return otpauth:// provisioning URI to show as QR code for authenticator apps.
+//
+//	otpauth://totp/Wengold:user@wengold.net?secret=JBSWY3DPEHPK3PXP&issuer=Wengold&algorithm=SHA1&digits=6&period=30
+func (t *TOTP) URI(secret, account string) string {
+	return provisionURI("totp", secret, account, t.opts, "period", strconv.Itoa(t.opts.Period))
+}
+
+// Return otpauth:// provisioning URI of HOTP with initial counter.
+func HOTPURI(secret, account string, counter uint64, opts *Options) string {
+	return provisionURI("hotp", secret, account, fillOptions(opts), "counter", strconv.FormatUint(counter, 10))
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Return time step counter of the given time.
+func (t *TOTP) counter(at time.Time) uint64 {
+	return uint64(at.Unix()) / uint64(t.opts.Period)
+}
+
+// Return options copy with default values.
+func fillOptions(opts *Options) Options {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Digits != 8 {
+		o.Digits = 6
+	}
+	if o.Period <= 0 {
+		o.Period = 30
+	}
+	if o.Skew < 0 {
+		o.Skew = 0
+	} else if o.Skew == 0 {
+		o.Skew = 1
+	}
+
+	switch o.Algorithm = strings.ToUpper(o.Algorithm); o.Algorithm {
+	case SHA256, SHA512:
+	default:
+		o.Algorithm = SHA1
+	}
+	return o
+}
+
+// Decode base32 secret, it ignore spaces and padding chars.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := b32.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, invar.ErrInvalidParams
+	}
+	return key, nil
+}
+
+// Generate HOTP code by dynamic truncation of HMAC value.
+func generate(key []byte, counter uint64, o Options) string {
+	var hf func() hash.Hash
+	switch o.Algorithm {
+	case SHA256:
+		hf = sha256.New
+	case SHA512:
+		hf = sha512.New
+	default:
+		hf = sha1.New
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(hf, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < o.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", o.Digits, value%mod)
+}
+
+// Compare codes in constant time.
+func equalCode(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Return otpauth:// URI with label and query params.
+func provisionURI(kind, secret, account string, o Options, key, value string) string {
+	label := account
+	if o.Issuer != "" {
+		label = o.Issuer + ":" + account
+	}
+
+	query := url.Values{}
+	query.Set("secret", strings.TrimRight(strings.ToUpper(secret), "="))
+	if o.Issuer != "" {
+		query.Set("issuer", o.Issuer)
+	}
+	query.Set("algorithm", o.Algorithm)
+	query.Set("digits", strconv.Itoa(o.Digits))
+	query.Set(key, value)
+
+	u := url.URL{Scheme: "otpauth", Host: kind, Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package otp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/secure/otp, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+// RFC 6238 test secrets of SHA1, SHA256, SHA512.
+var (
+	_test_sha1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	_test_sha256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+	_test_sha512 = base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 Appendix D test values.
+	wants := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, want := range wants {
+		if code, err := HOTP(_test_sha1, uint64(i), nil); err != nil || code != want {
+			t.Fatal("Counter:", i, "want:", want, "but output:", code, err)
+		}
+	}
+
+	if next, ok := VerifyHOTP(_test_sha1, "969429", 1, 3, nil); !ok || next != 4 {
+		t.Fatal("Verify HOTP in window failed, next:", next)
+	} else if _, ok := VerifyHOTP(_test_sha1, "520489", 1, 3, nil); ok {
+		t.Fatal("Verified HOTP out of window!")
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	cases := []struct {
+		Case   string
+		Secret string
+		Algo   string
+		Unix   int64
+		Want   string
+	}{
+		{"SHA1  ", _test_sha1, SHA1, 59, "94287082"},
+		{"SHA256", _test_sha256, SHA256, 59, "46119246"},
+		{"SHA512", _test_sha512, SHA512, 59, "90693936"},
+		{"SHA1  ", _test_sha1, SHA1, 1111111109, "07081804"},
+		{"SHA256", _test_sha256, SHA256, 1234567890, "91819424"},
+		{"SHA512", _test_sha512, SHA512, 20000000000, "47863826"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			totp := NewTOTP(&Options{Digits: 8, Algorithm: c.Algo}, nil)
+			code, err := totp.Generate(c.Secret, time.Unix(c.Unix, 0))
+			if err != nil || code != c.Want {
+				t.Fatal("Want:", c.Want, "but output:", code, err)
+			}
+		})
+	}
+}
+
+func TestTOTPReplay(t *testing.T) {
+	totp := NewTOTP(nil, NewMemoryStore())
+	now := time.Now()
+	code, _ := totp.Generate(_test_sha1, now.Add(-30*time.Second))
+
+	if ok, err := totp.VerifyAt("uid", _test_sha1, code, now); err != nil || !ok {
+		t.Fatal("Verify code in skew failed, err:", err)
+	} else if ok, _ := totp.VerifyAt("uid", _test_sha1, code, now); ok {
+		t.Fatal("Verified replayed code!")
+	} else if ok, _ := totp.VerifyAt("uid", _test_sha1, code, now.Add(time.Minute)); ok {
+		t.Fatal("Verified code out of skew!")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := NewTOTP(&Options{Issuer: "Wengold"}, nil).URI("JBSWY3DPEHPK3PXP", "user@wengold.net")
+	want := "otpauth://totp/Wengold:user@wengold.net?algorithm=SHA1&digits=6&issuer=Wengold&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != want {
+		t.Fatal("Want:", want, "but output:", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(10)
+	if err != nil || len(codes) != 10 || len(hashes) != 10 {
+		t.Fatal("Generate recovery codes, err:", err)
+	}
+
+	if idx := VerifyRecoveryCode(strings.ToLower(codes[3]), hashes); idx != 3 {
+		t.Fatal("Want matched index 3, but output:", idx)
+	} else if idx := VerifyRecoveryCode("AAAAA-AAAAA", hashes); idx != -1 {
+		t.Fatal("Matched invalid recovery code at:", idx)
+	}
+	t.Log("Recovery codes:", codes)
+}
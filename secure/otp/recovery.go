@@ -0,0 +1,71 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// Recovery code chars without confusing chars of 0, 1, I, L, O, U.
+const recoveryChars = "23456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Generate single-use recovery codes as 'XXXXX-XXXXX' format, and return
+// the codes to show user once, and the hashes to store.
+//
+//	codes, hashes, _ := otp.NewRecoveryCodes(10)
+//	// save hashes to database, then show codes to user.
+func NewRecoveryCodes(count int) ([]string, []string, error) {
+	codes, hashes := make([]string, 0, count), make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		buf := make([]byte, 10)
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return nil, nil, err
+		}
+
+		for j, b := range buf {
+			// 256 % 30 bias is small enough for 50 bits code.
+			buf[j] = recoveryChars[int(b)%len(recoveryChars)]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		codes, hashes = append(codes, code), append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// Hash recovery code by sha256 as hex string, it ignore case, spaces
+// and dash chars of code.
+func HashRecoveryCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(code))
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
+
+// Verify recovery code with stored hashes in constant time, and return the
+// matched index, or -1 when not matched, the caller must remove the matched
+// hash from store to make the code single-use.
+//
+//	if idx := otp.VerifyRecoveryCode(code, hashes); idx >= 0 {
+//		hashes = append(hashes[:idx], hashes[idx+1:]...) // save hashes
+//	}
+func VerifyRecoveryCode(code string, hashes []string) int {
+	matched, hash := -1, []byte(HashRecoveryCode(code))
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(h))) == 1 {
+			matched = i
+		}
+	}
+	return matched
+}
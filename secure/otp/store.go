@@ -0,0 +1,50 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package otp
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// In-memory used codes store for single server, use a shared store such
+// as redis when deploy multiple servers.
+type MemoryStore struct {
+	mutex sync.Mutex
+	used  map[string]time.Time // used key and counter mapping to expire time
+}
+
+// Create in-memory used codes store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{used: make(map[string]time.Time)}
+}
+
+// Mark the counter of key as used, it return false when already used,
+// and clear the expired records on each calling.
+func (s *MemoryStore) Use(key string, counter uint64, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for k, expire := range s.used {
+		if now.After(expire) {
+			delete(s.used, k)
+		}
+	}
+
+	k := key + "@" + strconv.FormatUint(counter, 10)
+	if _, ok := s.used[k]; ok {
+		return false, nil
+	}
+	s.used[k] = now.Add(ttl)
+	return true, nil
+}
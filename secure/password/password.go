@@ -0,0 +1,335 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/wengoldx/xcore/invar"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported password hash algorithms.
+const (
+	Argon2id = "argon2id" // Recommended, output as $argon2id$v=19$m=65536,t=3,p=2$salt$hash
+	Bcrypt   = "bcrypt"   // Compatible, output as $2a$10$salthash
+	Scrypt   = "scrypt"   // Compatible, output as $scrypt$ln=15,r=8,p=1$salt$hash
+)
+
+// Password hash params, only the fields of chosen algorithm are used.
+type Params struct {
+	Algorithm string // One of Argon2id, Bcrypt, Scrypt
+
+	Memory  uint32 // Argon2id memory in KiB
+	Time    uint32 // Argon2id iterations
+	Threads uint8  // Argon2id parallelism
+
+	LogN int // Scrypt CPU/memory cost as log2(N)
+	R    int // Scrypt block size
+	P    int // Scrypt parallelism
+
+	Cost int // Bcrypt cost
+
+	SaltLen int // Salt length in bytes for argon2id and scrypt
+	KeyLen  int // Hash length in bytes for argon2id and scrypt
+}
+
+// Password hasher to hash and verify passwords by the given params.
+type Hasher struct {
+	params Params
+}
+
+// Default params of argon2id as OWASP recommended.
+var DefaultParams = Params{
+	Algorithm: Argon2id, Memory: 64 * 1024, Time: 3, Threads: 2, SaltLen: 16, KeyLen: 32,
+}
+
+// Default hasher used by package level Hash, Verify and NeedsRehash.
+var DefaultHasher = NewHasher(DefaultParams)
+
+// Legacy params of secure.NewHash(), the salt is stored outside of hash.
+const (
+	legacyLogN = 14 // N = 16384
+	legacyR    = 8
+	legacyP    = 1
+)
+
+// Create a password hasher, the empty fields of params will filled by
+// default values of the algorithm.
+func NewHasher(params Params) *Hasher {
+	switch params.Algorithm {
+	case Bcrypt:
+		params.Cost = defInt(params.Cost, bcrypt.DefaultCost)
+	case Scrypt:
+		params.LogN = defInt(params.LogN, 15)
+		params.R, params.P = defInt(params.R, 8), defInt(params.P, 1)
+	default:
+		params.Algorithm = Argon2id
+		if params.Memory == 0 {
+			params.Memory = DefaultParams.Memory
+		}
+		if params.Time == 0 {
+			params.Time = DefaultParams.Time
+		}
+		if params.Threads == 0 {
+			params.Threads = DefaultParams.Threads
+		}
+	}
+	params.SaltLen = defInt(params.SaltLen, DefaultParams.SaltLen)
+	params.KeyLen = defInt(params.KeyLen, DefaultParams.KeyLen)
+	return &Hasher{params: params}
+}
+
+// Hash password by default hasher, see Hasher.Hash().
+func Hash(password string) (string, error) {
+	return DefaultHasher.Hash(password)
+}
+
+// Verify password by default hasher, see Hasher.Verify().
+func Verify(password, encoded string) (bool, error) {
+	return DefaultHasher.Verify(password, encoded)
+}
+
+// Check hash params by default hasher, see Hasher.NeedsRehash().
+func NeedsRehash(encoded string) bool {
+	return DefaultHasher.NeedsRehash(encoded)
+}
+
+// Hash password with random salt, and return the PHC format string which
+// contain algorithm, params, salt and hash, so it can be directly stored.
+//
+//	hash, _ := password.Hash("123456")
+//	// => $argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHQ$aGFzaGhhc2g
+func (h *Hasher) Hash(password string) (string, error) {
+	p := h.params
+	if p.Algorithm == Bcrypt {
+		buf, err := bcrypt.GenerateFromPassword([]byte(password), p.Cost)
+		return string(buf), err
+	}
+
+	salt := make([]byte, p.SaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+
+	if p.Algorithm == Scrypt {
+		key, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLen)
+		if err != nil {
+			return "", err
+		}
+		return encodeScrypt(p.LogN, p.R, p.P, salt, key), nil
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(p.KeyLen))
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2id, argon2.Version,
+		p.Memory, p.Time, p.Threads, b64Encode(salt), b64Encode(key)), nil
+}
+
+// Verify password with the encoded hash in constant time, it support all
+// the algorithms whatever the hasher params, and legacy hash converted by
+// FromLegacy(), it return invar.ErrUnsupportFormat for unknown format.
+func (h *Hasher) Verify(password, encoded string) (bool, error) {
+	ph, err := parse(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	switch ph.algorithm {
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	case Scrypt:
+		key, err := scrypt.Key([]byte(password), ph.salt, 1<<ph.params.LogN, ph.params.R, ph.params.P, len(ph.key))
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare(key, ph.key) == 1, nil
+	default:
+		p := ph.params
+		key := argon2.IDKey([]byte(password), ph.salt, p.Time, p.Memory, p.Threads, uint32(len(ph.key)))
+		return subtle.ConstantTimeCompare(key, ph.key) == 1, nil
+	}
+}
+
+// Check whether the encoded hash created by other algorithm or params
+// of current hasher, the caller should rehash password after verified.
+//
+//	if ok, _ := hasher.Verify(pwd, account.Hash); ok && hasher.NeedsRehash(account.Hash) {
+//		account.Hash, _ = hasher.Hash(pwd) // update account hash
+//	}
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	ph, err := parse(encoded)
+	if err != nil || ph.algorithm != h.params.Algorithm {
+		return true
+	}
+
+	p, o := h.params, ph.params
+	switch ph.algorithm {
+	case Bcrypt:
+		return o.Cost != p.Cost
+	case Scrypt:
+		return o.LogN != p.LogN || o.R != p.R || o.P != p.P ||
+			len(ph.salt) != p.SaltLen || len(ph.key) != p.KeyLen
+	default:
+		return ph.version != argon2.Version || o.Memory != p.Memory || o.Time != p.Time ||
+			o.Threads != p.Threads || len(ph.salt) != p.SaltLen || len(ph.key) != p.KeyLen
+	}
+}
+
+// Convert the legacy hex hash and salt created by secure.NewHash() to the
+// scrypt PHC format string, so it can be verified by Verify(), and always
+// need rehash by argon2id hasher.
+//
+//	encoded, _ := password.FromLegacy(account.Hash, account.Salt)
+//	if ok, _ := password.Verify(pwd, encoded); ok {
+//		account.Hash, _ = password.Hash(pwd) // migrate to argon2id
+//	}
+func FromLegacy(hash, salt string) (string, error) {
+	key, err := hex.DecodeString(hash)
+	if err != nil || len(key) == 0 {
+		return "", invar.ErrUnsupportFormat
+	}
+	return encodeScrypt(legacyLogN, legacyR, legacyP, []byte(salt), key), nil
+}
+
+// Verify password with the legacy hex hash and salt of secure.NewHash().
+func VerifyLegacy(password, hash, salt string) bool {
+	encoded, err := FromLegacy(hash, salt)
+	if err != nil {
+		return false
+	}
+	ok, _ := DefaultHasher.Verify(password, encoded)
+	return ok
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal Utils Methods                                          */
+/* ------------------------------------------------------------------- */
+
+// Parsed hash infos from PHC format string.
+type phcHash struct {
+	algorithm string
+	version   int
+	params    Params
+	salt      []byte
+	key       []byte
+}
+
+// Parse the PHC format string of argon2id, scrypt and bcrypt hash.
+func parse(encoded string) (*phcHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 4 || parts[0] != "" {
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	ph := &phcHash{algorithm: parts[1]}
+	switch ph.algorithm {
+	case "2a", "2b", "2y":
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return nil, invar.ErrUnsupportFormat
+		}
+		ph.algorithm, ph.params.Cost = Bcrypt, cost
+		return ph, nil
+	case Argon2id:
+		if len(parts) != 6 || !strings.HasPrefix(parts[2], "v=") {
+			return nil, invar.ErrUnsupportFormat
+		}
+		ver, err := strconv.Atoi(strings.TrimPrefix(parts[2], "v="))
+		if err != nil {
+			return nil, invar.ErrUnsupportFormat
+		}
+		ph.version, parts = ver, append(parts[:2], parts[3:]...)
+	case Scrypt:
+		if len(parts) != 5 {
+			return nil, invar.ErrUnsupportFormat
+		}
+	default:
+		return nil, invar.ErrUnsupportFormat
+	}
+
+	kvs, err := parseParams(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if ph.algorithm == Argon2id {
+		ph.params.Memory, ph.params.Time = uint32(kvs["m"]), uint32(kvs["t"])
+		ph.params.Threads = uint8(kvs["p"])
+		if ph.params.Memory == 0 || ph.params.Time == 0 || ph.params.Threads == 0 {
+			return nil, invar.ErrUnsupportFormat
+		}
+	} else {
+		ph.params.LogN, ph.params.R, ph.params.P = kvs["ln"], kvs["r"], kvs["p"]
+		if ph.params.LogN <= 0 || ph.params.LogN >= bits.UintSize || ph.params.R <= 0 || ph.params.P <= 0 {
+			return nil, invar.ErrUnsupportFormat
+		}
+	}
+
+	if ph.salt, err = b64Decode(parts[3]); err != nil {
+		return nil, invar.ErrUnsupportFormat
+	} else if ph.key, err = b64Decode(parts[4]); err != nil || len(ph.key) == 0 {
+		return nil, invar.ErrUnsupportFormat
+	}
+	return ph, nil
+}
+
+// Parse params string as 'm=65536,t=3,p=2' to key values.
+func parseParams(params string) (map[string]int, error) {
+	kvs := make(map[string]int)
+	for _, kv := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, invar.ErrUnsupportFormat
+		}
+		num, err := strconv.Atoi(value)
+		if err != nil || num < 0 {
+			return nil, invar.ErrUnsupportFormat
+		}
+		kvs[key] = num
+	}
+	return kvs, nil
+}
+
+// Return scrypt PHC format string.
+func encodeScrypt(logN, r, p int, salt, key []byte) string {
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", Scrypt, logN, r, p, b64Encode(salt), b64Encode(key))
+}
+
+// Encode datas as standard base64 string without padding.
+func b64Encode(src []byte) string {
+	return base64.RawStdEncoding.EncodeToString(src)
+}
+
+// Decode standard base64 string without padding.
+func b64Decode(src string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(src)
+}
+
+// Return def value when the given value not positive.
+func defInt(value, def int) int {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
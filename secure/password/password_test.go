@@ -0,0 +1,97 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wengoldx/xcore/secure"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// -------------------------------------------------------------------
+// USAGE: Enter ~/xcore/secure/password, and excute command to test.
+//
+//	go test -v -cover
+// -------------------------------------------------------------------
+
+func TestHashVerify(t *testing.T) {
+	cases := []struct {
+		Case   string
+		Params Params
+		Prefix string
+	}{
+		{"Argon2id", Params{Algorithm: Argon2id, Memory: 1024, Time: 1, Threads: 1}, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"Scrypt  ", Params{Algorithm: Scrypt, LogN: 10}, "$scrypt$ln=10,r=8,p=1$"},
+		{"Bcrypt  ", Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost}, "$2a$04$"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Case, func(t *testing.T) {
+			h := NewHasher(c.Params)
+			encoded, err := h.Hash("123456")
+			if err != nil {
+				t.Fatal("Hash password, err:", err)
+			} else if !strings.HasPrefix(encoded, c.Prefix) {
+				t.Fatal("Want prefix:", c.Prefix, "but output:", encoded)
+			}
+
+			if ok, err := h.Verify("123456", encoded); err != nil || !ok {
+				t.Fatal("Verify right password failed, err:", err)
+			} else if ok, _ := h.Verify("654321", encoded); ok {
+				t.Fatal("Verified wrong password!")
+			} else if h.NeedsRehash(encoded) {
+				t.Fatal("Unexpected rehash for same params")
+			} else if !DefaultHasher.NeedsRehash(encoded) && c.Params.Algorithm != Argon2id {
+				t.Fatal("Expect rehash for other algorithm")
+			}
+			t.Log("Hashed:", encoded)
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h := NewHasher(Params{Memory: 1024, Time: 1, Threads: 1})
+	encoded, _ := h.Hash("123456")
+	if !NewHasher(Params{Memory: 2048, Time: 1, Threads: 1}).NeedsRehash(encoded) {
+		t.Fatal("Expect rehash for changed memory")
+	} else if !h.NeedsRehash("invalid hash") {
+		t.Fatal("Expect rehash for invalid hash")
+	}
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	salt, _ := secure.NewSalt()
+	hash, err := secure.NewHash("123456", salt)
+	if err != nil {
+		t.Fatal("Create legacy hash, err:", err)
+	}
+
+	if !VerifyLegacy("123456", hash, salt) {
+		t.Fatal("Verify legacy hash failed")
+	} else if VerifyLegacy("654321", hash, salt) {
+		t.Fatal("Verified wrong legacy password!")
+	}
+
+	encoded, _ := FromLegacy(hash, salt)
+	if !NeedsRehash(encoded) {
+		t.Fatal("Expect rehash for legacy hash")
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	for _, encoded := range []string{"", "$md5$abc", "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5", "$scrypt$ln=x$c2FsdA$a2V5"} {
+		if _, err := Verify("123456", encoded); err == nil {
+			t.Fatal("Expect error for invalid hash:", encoded)
+		}
+	}
+}
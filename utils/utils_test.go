@@ -13,6 +13,8 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -167,3 +169,77 @@ func TestParseRouters(t *testing.T) {
 	}
 
 }
+
+func TestGenOpenAPI(t *testing.T) {
+	xt.UseDebugLogger()
+
+	dir := t.TempDir()
+	codes := "package controllers\n\n" +
+		"import \"github.com/wengoldx/xcore/mvc\"\n\n" +
+		"// Account controller\n" +
+		"type AccController struct { mvc.WRoleController }\n\n" +
+		"type Accout struct {\n" +
+		"	Acc  string   `json:\"acc\" validate:\"required,max=32\"`\n" +
+		"	Role string   `json:\"role\" validate:\"oneof=admin user\"`\n" +
+		"	Tags []string `json:\"tags\" validate:\"min=1,dive,email\"`\n" +
+		"}\n\n" +
+		"//	@Description Account login\n" +
+		"//	@Param Author header string true \"WENGOLD-V2.0\"\n" +
+		"//	@Success 200 {object} Accout \"account datas\"\n" +
+		"//	@router /login/:id [post]\n" +
+		"func (c *AccController) AccLogin() {\n" +
+		"	ps := &Accout{}\n" +
+		"	c.DoAfterValidated(ps, nil)\n" +
+		"}\n\n" +
+		"//	@router /status [get,post]\n" +
+		"func (c *AccController) AccStatus() {\n" +
+		"	c.ResponseOK()\n" +
+		"}\n\n" +
+		"//	@router /detail [get]\n" +
+		"func (c *AccController) AccDetail() {\n" +
+		"	mvc.HandleAuth(c, func(ctx context.Context, a *mvc.WAuths, in *Accout) (*Accout, error) {\n" +
+		"		return in, nil\n" +
+		"	})\n" +
+		"}\n"
+	if err := os.WriteFile(filepath.Join(dir, "acc.go"), []byte(codes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &OAOptions{Version: "1.0.0", Routers: map[string]string{"AccController": "/v1/acc"}}
+	doc, err := GenOpenAPI(opts, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, ok := doc.Paths["/v1/acc/login/{id}"]
+	if !ok || (*item)["post"] == nil {
+		t.Fatal("Not found router: /v1/acc/login/{id}")
+	}
+
+	op := (*item)["post"]
+	if op.RequestBody == nil || len(op.Security) == 0 || op.Responses["401"] == nil {
+		t.Fatal("Invalid operation:", op)
+	} else if len(op.Parameters) != 1 || op.Parameters[0].In != "path" || !op.Parameters[0].Required {
+		t.Fatal("Undeclared path param:", op.Parameters)
+	}
+
+	detail := (*doc.Paths["/v1/acc/detail"])["get"]
+	if len(detail.Parameters) != 2 || detail.Responses["200"].Content == nil || len(detail.Security) == 0 {
+		t.Fatal("Invalid typed handler operation:", detail)
+	}
+
+	status := doc.Paths["/v1/acc/status"]
+	if status == nil || (*status)["get"] == nil || (*status)["post"] == nil {
+		t.Fatal("Not found multiple methods router: /v1/acc/status")
+	} else if get, post := (*status)["get"], (*status)["post"]; get == post ||
+		get.OperationID != "AccController.AccStatus_get" || post.OperationID != "AccController.AccStatus_post" {
+		t.Fatal("Duplicated operationId:", get.OperationID, post.OperationID)
+	}
+
+	schema := doc.Components.Schemas["controllers.Accout"]
+	if schema == nil || !Contain(schema.Required, "acc") || len(schema.Properties["role"].Enum) != 2 {
+		t.Fatal("Invalid params schema:", schema)
+	} else if tags := schema.Properties["tags"]; tags.Items.Format != "email" || *tags.MinItems != 1 {
+		t.Fatal("Invalid array schema:", tags)
+	}
+}
@@ -0,0 +1,1118 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package utils
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego"
+	"github.com/wengoldx/xcore/invar"
+	"github.com/wengoldx/xcore/logger"
+)
+
+// OpenAPI 3.1 document generated from controller annotations.
+const (
+	_openapi_json_file = "./swagger/openapi.json"
+	_openapi_version   = "3.1.0"
+	_oa_schemas_ref    = "#/components/schemas/"
+	_oa_responses_ref  = "#/components/responses/"
+	_oa_mime_json      = "application/json"
+	_oa_mime_form      = "multipart/form-data"
+)
+
+// OpenAPI document root object.
+type OpenAPI struct {
+	OpenAPI    string                 `json:"openapi"`
+	Info       *OAInfo                `json:"info"`
+	Servers    []*OAServer            `json:"servers,omitempty"`
+	Tags       []*OATag               `json:"tags,omitempty"`
+	Paths      map[string]*OAPathItem `json:"paths"`
+	Components *OAComponents          `json:"components"`
+}
+
+// OpenAPI document informations.
+type OAInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI server url.
+type OAServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPI operations group tag, one controller one tag.
+type OATag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI router path operations, mapped by lower http method.
+type OAPathItem map[string]*OAOperation
+
+// OpenAPI router path operation of one http method.
+type OAOperation struct {
+	Tags        []string               `json:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	OperationID string                 `json:"operationId,omitempty"`
+	Parameters  []*OAParameter         `json:"parameters,omitempty"`
+	RequestBody *OARequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OAResponse `json:"responses"`
+	Security    []map[string][]string  `json:"security,omitempty"`
+}
+
+// OpenAPI operation input param of path, query or header.
+type OAParameter struct {
+	Name        string    `json:"name"`
+	In          string    `json:"in"`
+	Description string    `json:"description,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	Schema      *OASchema `json:"schema,omitempty"`
+}
+
+// OpenAPI operation request body.
+type OARequestBody struct {
+	Description string              `json:"description,omitempty"`
+	Required    bool                `json:"required,omitempty"`
+	Content     map[string]*OAMedia `json:"content"`
+}
+
+// OpenAPI operation response, or a reference of components responses.
+type OAResponse struct {
+	Ref         string              `json:"$ref,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Content     map[string]*OAMedia `json:"content,omitempty"`
+}
+
+// OpenAPI media type of request body or response.
+type OAMedia struct {
+	Schema *OASchema `json:"schema,omitempty"`
+}
+
+// OpenAPI json schema, the Type field maybe string or strings array.
+type OASchema struct {
+	Ref                  string               `json:"$ref,omitempty"`
+	Type                 any                  `json:"type,omitempty"`
+	Format               string               `json:"format,omitempty"`
+	Description          string               `json:"description,omitempty"`
+	Items                *OASchema            `json:"items,omitempty"`
+	Properties           map[string]*OASchema `json:"properties,omitempty"`
+	AdditionalProperties *OASchema            `json:"additionalProperties,omitempty"`
+	Required             []string             `json:"required,omitempty"`
+	Enum                 []any                `json:"enum,omitempty"`
+	Pattern              string               `json:"pattern,omitempty"`
+	Minimum              *float64             `json:"minimum,omitempty"`
+	Maximum              *float64             `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64             `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64             `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                 `json:"minLength,omitempty"`
+	MaxLength            *int                 `json:"maxLength,omitempty"`
+	MinItems             *int                 `json:"minItems,omitempty"`
+	MaxItems             *int                 `json:"maxItems,omitempty"`
+	Validate             string               `json:"x-validate,omitempty"` // Original validate tag.
+}
+
+// OpenAPI reuseable components.
+type OAComponents struct {
+	Schemas         map[string]*OASchema         `json:"schemas,omitempty"`
+	Responses       map[string]*OAResponse       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*OASecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPI security scheme, here only use apiKey in header.
+type OASecurityScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+}
+
+// Options to generate OpenAPI document.
+type OAOptions struct {
+	Title       string            // Document title, default beego app name, or 'Restful APIs' when app name empty.
+	Version     string            // Document version, like '1.2.3'.
+	Description string            // Document description, optional.
+	Servers     []string          // Server urls, like 'http://127.0.0.1:8080'.
+	Routers     map[string]string // Controller router prefix, like 'AccController': '/v1/acc'.
+	TypeDirs    []string          // Extra params struct dirs, like './types'.
+}
+
+// Generate OpenAPI 3.1 document from controllers source codes, it parse the
+// '@Title', '@Summary', '@Description', '@Param', '@Success', '@Failure',
+// '@router' annotations of controller methods, and the 'json', 'validate'
+// tags of param structs used by DoAfterValidated() and others.
+//
+// # USAGE:
+//
+//	doc, err := utils.GenOpenAPI(&utils.OAOptions{
+//		Version:  "1.0.0",
+//		Routers:  map[string]string{"AccController": "/v1/acc"},
+//		TypeDirs: []string{"./types"},
+//	}, "./controllers")
+//
+// The controller method annotations like :
+//
+//	//	@Description Restful api bind with /login on POST method
+//	//	@Param Author header string true "WENGOLD-V1.2"
+//	//	@Param Token  header string true "Authentication token"
+//	//	@Param data   body   types.Accout true "input param description"
+//	//	@Success 200 {object} types.Detail "response data description"
+//	//	@router /login [post]
+//	func (c *AccController) AccLogin() { ... }
+//
+// The 'Author' and 'Token' header params will output as security schemes, and
+// the input params and result types of mvc.Handle(), mvc.HandleAuth() typed
+// handlers will output as request params and response schemas.
+func GenOpenAPI(opts *OAOptions, dirs ...string) (*OpenAPI, error) {
+	if opts == nil {
+		opts = &OAOptions{}
+	}
+
+	g := newOAGenerator(opts)
+	for _, dir := range append(append([]string{}, opts.TypeDirs...), dirs...) {
+		if err := g.parseDir(dir); err != nil {
+			logger.E("Parse codes dir:", dir, "err:", err)
+			return nil, err
+		}
+	}
+
+	g.genOperations()
+	logger.I("Generated openapi with", len(g.doc.Paths), "paths")
+	return g.doc, nil
+}
+
+// Generate OpenAPI 3.1 document and save to file, the outfile maybe
+// empty to use default './swagger/openapi.json' file.
+//
+//	See GenOpenAPI() for more usage informations.
+func SaveOpenAPI(outfile string, opts *OAOptions, dirs ...string) error {
+	doc, err := GenOpenAPI(opts, dirs...)
+	if err != nil {
+		return err
+	}
+
+	outfile = Condition(outfile == "", _openapi_json_file, outfile)
+	buff, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		logger.E("Marshal openapi, err:", err)
+		return err
+	}
+
+	dir, filename := filepath.Split(outfile)
+	return SaveFile(Condition(dir == "", ".", dir), filename, buff)
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal OpenAPI Generator                                      */
+/* ------------------------------------------------------------------- */
+
+// Controller method parsed from codes.
+type oaMethod struct {
+	pkg  string        // Controller package name.
+	ctl  string        // Controller type name.
+	decl *ast.FuncDecl // Controller method declare.
+}
+
+// Struct type parsed from codes.
+type oaStruct struct {
+	pkg  string          // Struct package name.
+	doc  string          // Struct comments.
+	node *ast.StructType // Struct node.
+}
+
+// OpenAPI document generator.
+type oaGenerator struct {
+	opts    *OAOptions
+	doc     *OpenAPI
+	structs map[string]*oaStruct // Key as 'pkg.Name'.
+	ctls    map[string]string    // Controller type name and comments.
+	auths   map[string]bool      // Controllers which embedded auth controller.
+	methods []*oaMethod
+	tags    map[string]bool
+}
+
+// Create a generator with default components.
+func newOAGenerator(opts *OAOptions) *oaGenerator {
+	doc := &OpenAPI{
+		OpenAPI: _openapi_version,
+		Info:    &OAInfo{Title: opts.Title, Version: opts.Version, Description: opts.Description},
+		Paths:   make(map[string]*OAPathItem),
+		Components: &OAComponents{
+			Schemas:   make(map[string]*OASchema),
+			Responses: make(map[string]*OAResponse),
+			SecuritySchemes: map[string]*OASecurityScheme{
+				"Author": {Type: "apiKey", Name: "Author", In: "header",
+					Description: "Fixed auth keyword, such as WENGOLD-V1.2, WENGOLD-V2.0"},
+				"Token": {Type: "apiKey", Name: "Token", In: "header",
+					Description: "Authenticate JWT token responsed by login success"},
+			},
+		},
+	}
+
+	if doc.Info.Title == "" {
+		doc.Info.Title = Condition(beego.BConfig.AppName == "", "Restful APIs", beego.BConfig.AppName)
+	}
+	for _, server := range opts.Servers {
+		doc.Servers = append(doc.Servers, &OAServer{URL: server})
+	}
+
+	// error envelope: protect mode response empty body with error state,
+	// and unprotect mode response extend error datas on 202 state.
+	for _, code := range []int{invar.E400ParseParams, invar.E401Unauthorized,
+		invar.E403PermissionDenied, invar.E404Exception} {
+		doc.Components.Responses[oaErrorKey(code)] = &OAResponse{Description: invar.StatusText(code)}
+	}
+	doc.Components.Responses[oaErrorKey(invar.StatusExError)] = &OAResponse{
+		Description: invar.StatusText(invar.StatusExError),
+		Content:     map[string]*OAMedia{_oa_mime_json: {Schema: &OASchema{}}},
+	}
+
+	return &oaGenerator{
+		opts: opts, doc: doc,
+		structs: make(map[string]*oaStruct),
+		ctls:    make(map[string]string),
+		auths:   make(map[string]bool),
+		tags:    make(map[string]bool),
+	}
+}
+
+// Parse all go source files (exclude tests) under the given dir.
+func (g *oaGenerator) parseDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		g.parseFile(file)
+	}
+	return nil
+}
+
+// Collect struct types and controller router methods from source file.
+func (g *oaGenerator) parseFile(file *ast.File) {
+	pkg := file.Name.Name
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				doc := d.Doc
+				if ts.Doc != nil {
+					doc = ts.Doc
+				}
+				g.structs[pkg+"."+ts.Name.Name] = &oaStruct{pkg: pkg, doc: oaFirstLine(doc), node: st}
+				if g.isAuthController(st) {
+					g.auths[ts.Name.Name] = true
+				}
+				if strings.HasSuffix(ts.Name.Name, "Controller") {
+					g.ctls[ts.Name.Name] = oaFirstLine(doc)
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || d.Doc == nil || len(d.Recv.List) == 0 {
+				continue
+			}
+			if !strings.Contains(d.Doc.Text(), "@router") {
+				continue
+			}
+			g.methods = append(g.methods, &oaMethod{pkg: pkg, ctl: oaRecvName(d.Recv.List[0].Type), decl: d})
+		}
+	}
+}
+
+// Check the controller struct whether embedded WAuthController or WRoleController.
+func (g *oaGenerator) isAuthController(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			switch name := oaTypeString(field.Type); name {
+			case "mvc.WAuthController", "mvc.WRoleController", "WAuthController", "WRoleController":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Generate path operations from all parsed controller methods.
+func (g *oaGenerator) genOperations() {
+	sort.Slice(g.methods, func(i, j int) bool {
+		if g.methods[i].ctl != g.methods[j].ctl {
+			return g.methods[i].ctl < g.methods[j].ctl
+		}
+		return g.methods[i].decl.Name.Name < g.methods[j].decl.Name.Name
+	})
+
+	for _, m := range g.methods {
+		prefix, tag := g.routerPrefix(m.ctl)
+		op := &OAOperation{
+			Tags: []string{tag}, OperationID: m.ctl + "." + m.decl.Name.Name,
+			Responses: make(map[string]*OAResponse),
+		}
+
+		router, methods, secured, validated := "", []string{}, false, false
+		for _, line := range strings.Split(m.decl.Doc.Text(), "\n") {
+			line = strings.TrimSpace(line)
+			key, value, _ := strings.Cut(line, " ")
+			value = strings.TrimSpace(value)
+
+			switch strings.ToLower(key) {
+			case "@title", "@summary":
+				op.Summary = value
+			case "@description":
+				op.Description = strings.TrimSpace(op.Description + "\n" + value)
+			case "@param":
+				if g.parseParam(m.pkg, op, value) {
+					secured = true
+				}
+			case "@success", "@failure":
+				g.parseResponse(m.pkg, op, value)
+			case "@router":
+				router, methods = oaParseRouter(value)
+			}
+		}
+
+		if router == "" || len(methods) == 0 {
+			logger.W("Invalid router of", op.OperationID)
+			continue
+		}
+
+		// parse params struct from Do* methods or typed handlers if not set body.
+		if call, ps, out := g.findParamsCall(m); call != "" {
+			validated = true
+			if call == "HandleAuth" || (g.auths[m.ctl] && strings.HasPrefix(call, "Do") &&
+				!strings.HasSuffix(call, "Insecure")) {
+				secured = true
+			}
+
+			if ps == "mvc.NoParams" {
+				ps = ""
+			}
+			if code := strconv.Itoa(invar.StatusOK); out != "" && op.Responses[code] == nil {
+				op.Responses[code] = &OAResponse{Description: invar.StatusText(invar.StatusOK),
+					Content: map[string]*OAMedia{_oa_mime_json: {Schema: g.typeSchema(m.pkg, out)}}}
+			}
+
+			if ps != "" && op.RequestBody == nil {
+				if strings.Contains(call, "Parsed") || (strings.HasPrefix(call, "Handle") && oaUrlMethods(methods)) {
+					g.appendQueryParams(m.pkg, op, ps)
+				} else {
+					op.RequestBody = &OARequestBody{Required: true,
+						Content: map[string]*OAMedia{_oa_mime_json: {Schema: g.typeSchema(m.pkg, ps)}}}
+				}
+			}
+		} else if g.auths[m.ctl] && g.callsAuthHeader(m) {
+			secured = true
+		}
+
+		if secured {
+			op.Security = []map[string][]string{{"Author": {}, "Token": {}}}
+			g.defaultError(op, invar.E401Unauthorized)
+			g.defaultError(op, invar.E403PermissionDenied)
+		}
+		if validated || len(op.Parameters) > 0 || op.RequestBody != nil {
+			g.defaultError(op, invar.E400ParseParams)
+			g.defaultError(op, invar.E404Exception)
+		}
+		if len(op.Responses) == 0 {
+			op.Responses[strconv.Itoa(invar.StatusOK)] = &OAResponse{Description: invar.StatusText(invar.StatusOK)}
+		}
+
+		path := oaNormalizePath(prefix + router)
+		oaPathParams(op, path)
+		item, ok := g.doc.Paths[path]
+		if !ok {
+			item = &OAPathItem{}
+			g.doc.Paths[path] = item
+		}
+		for _, method := range methods {
+			mop := op
+			if len(methods) > 1 { // clone operation to keep operationId unique.
+				mop = &OAOperation{}
+				*mop = *op
+				mop.OperationID = op.OperationID + "_" + method
+			}
+			(*item)[method] = mop
+		}
+		g.appendTag(tag, m.ctl)
+	}
+}
+
+// Return the controller router prefix and group tag name.
+func (g *oaGenerator) routerPrefix(ctl string) (string, string) {
+	prefix := strings.TrimSuffix(g.opts.Routers[ctl], "/")
+	if prefix != "" {
+		return prefix, prefix[strings.LastIndex(prefix, "/")+1:]
+	}
+	return "", strings.ToLower(strings.TrimSuffix(ctl, "Controller"))
+}
+
+// Append controller group tag if unexist.
+func (g *oaGenerator) appendTag(tag, ctl string) {
+	if !g.tags[tag] {
+		g.tags[tag] = true
+		g.doc.Tags = append(g.doc.Tags, &OATag{Name: tag, Description: g.ctls[ctl]})
+	}
+}
+
+// Set default error response if the state code not set by '@Failure'.
+func (g *oaGenerator) defaultError(op *OAOperation, code int) {
+	if key := strconv.Itoa(code); op.Responses[key] == nil {
+		op.Responses[key] = &OAResponse{Ref: _oa_responses_ref + oaErrorKey(code)}
+	}
+}
+
+// Parse '@Param name in type required "description"' annotation, it
+// return true when the param is 'Author' or 'Token' auth header.
+func (g *oaGenerator) parseParam(pkg string, op *OAOperation, value string) bool {
+	fields := oaSplitFields(value)
+	if len(fields) < 3 {
+		return false
+	}
+
+	name, in, typ := fields[0], fields[1], fields[2]
+	required := len(fields) > 3 && fields[3] == "true"
+	desc := ""
+	if len(fields) > 4 {
+		desc = fields[4]
+	}
+
+	switch in {
+	case "header":
+		if name == "Author" || name == "Token" {
+			return true
+		}
+	case "body":
+		op.RequestBody = &OARequestBody{Description: desc, Required: required,
+			Content: map[string]*OAMedia{_oa_mime_json: {Schema: g.typeSchema(pkg, typ)}}}
+		return false
+	case "formData":
+		if op.RequestBody == nil || op.RequestBody.Content[_oa_mime_form] == nil {
+			op.RequestBody = &OARequestBody{Required: true, Content: map[string]*OAMedia{
+				_oa_mime_form: {Schema: &OASchema{Type: "object", Properties: map[string]*OASchema{}}}}}
+		}
+		schema := op.RequestBody.Content[_oa_mime_form].Schema
+		prop := g.typeSchema(pkg, typ)
+		prop.Description = desc
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		return false
+	}
+
+	op.Parameters = append(op.Parameters, &OAParameter{
+		Name: name, In: in, Description: desc,
+		Required: required || in == "path", Schema: g.typeSchema(pkg, typ),
+	})
+	return false
+}
+
+// Parse '@Success 200 {object} types.Detail "description"' or
+// '@Failure 400 description' annotations.
+func (g *oaGenerator) parseResponse(pkg string, op *OAOperation, value string) {
+	fields := oaSplitFields(value)
+	if len(fields) == 0 {
+		return
+	}
+
+	resp, typ, rest := &OAResponse{}, "", fields[1:]
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "{") {
+		typ, rest = strings.Trim(rest[0], "{}"), rest[1:]
+		if (typ == "object" || typ == "array") && len(rest) > 0 {
+			if typ == "array" && !strings.HasPrefix(rest[0], "[]") {
+				rest[0] = "[]" + rest[0]
+			}
+			typ, rest = rest[0], rest[1:]
+		}
+	}
+
+	code, _ := strconv.Atoi(fields[0])
+	resp.Description = strings.Join(rest, " ")
+	if resp.Description == "" {
+		resp.Description = invar.StatusText(code)
+	}
+	if typ != "" {
+		resp.Content = map[string]*OAMedia{_oa_mime_json: {Schema: g.typeSchema(pkg, typ)}}
+	}
+	op.Responses[fields[0]] = resp
+}
+
+// Find params struct and Do* method or typed handler called in controller
+// method body, it return the called method name, params struct type name,
+// and the typed handler result type name.
+//
+//	c.DoAfterValidated(ps, func(s *WAuths) (int, any) { ... })
+//	mvc.Handle(c, func(ctx context.Context, in *types.In) (*types.Out, error) { ... })
+func (g *oaGenerator) findParamsCall(m *oaMethod) (string, string, string) {
+	if m.decl.Body == nil {
+		return "", "", ""
+	}
+
+	vars, call, ps, out := make(map[string]string), "", "", ""
+	ast.Inspect(m.decl.Body, func(n ast.Node) bool {
+		switch v := n.(type) {
+		case *ast.AssignStmt: // ps := &types.Accout{}
+			for i, rhs := range v.Rhs {
+				if i < len(v.Lhs) {
+					if ident, ok := v.Lhs[i].(*ast.Ident); ok {
+						if typ := oaCompositeType(rhs); typ != "" {
+							vars[ident.Name] = typ
+						}
+					}
+				}
+			}
+		case *ast.ValueSpec: // var ps types.Accout
+			if v.Type != nil {
+				for _, ident := range v.Names {
+					vars[ident.Name] = oaTypeString(v.Type)
+				}
+			}
+		case *ast.CallExpr:
+			fun := v.Fun
+			if index, ok := fun.(*ast.IndexListExpr); ok {
+				fun = index.X // mvc.Handle[In, Out](...)
+			}
+
+			sel, ok := fun.(*ast.SelectorExpr)
+			if !ok || len(v.Args) == 0 || call != "" {
+				return true
+			} else if name := sel.Sel.Name; name == "Handle" || name == "HandleAuth" {
+				if len(v.Args) > 1 {
+					if fn, ok := v.Args[1].(*ast.FuncLit); ok {
+						call, ps, out = name, oaLastParam(fn.Type.Params), oaLastParam(fn.Type.Results, true)
+					}
+				}
+				return true
+			} else if !strings.HasPrefix(name, "Do") {
+				return true
+			}
+
+			// ignore the c.WingController.DoAfterValidated() calls auth.
+			call = sel.Sel.Name
+			if inner, ok := sel.X.(*ast.SelectorExpr); ok && inner.Sel.Name == "WingController" {
+				call += "Insecure"
+			}
+
+			switch arg := v.Args[0].(type) {
+			case *ast.Ident:
+				ps = vars[arg.Name]
+			case *ast.UnaryExpr:
+				ps = oaCompositeType(arg)
+			}
+		}
+		return true
+	})
+	return call, ps, out
+}
+
+// Check controller method whether call c.AuthRequestHeader().
+func (g *oaGenerator) callsAuthHeader(m *oaMethod) bool {
+	found := false
+	if m.decl.Body != nil {
+		ast.Inspect(m.decl.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "AuthRequestHeader" {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// Append url query params from the fields of params struct for GET method,
+// only the simple value type fields with json tag can be parsed from url.
+func (g *oaGenerator) appendQueryParams(pkg string, op *OAOperation, ps string) {
+	key := strings.TrimPrefix(ps, "*")
+	if !strings.Contains(key, ".") {
+		key = pkg + "." + key
+	}
+
+	s, ok := g.structs[key]
+	if !ok {
+		return
+	}
+
+	for _, field := range s.node.Fields.List {
+		tags := oaFieldTags(field)
+		name, _, _ := strings.Cut(tags.Get("json"), ",")
+		schema := oaBuiltinSchema(oaTypeString(field.Type))
+		if name == "" || name == "-" || len(field.Names) == 0 || schema == nil {
+			continue
+		}
+
+		required := oaApplyValidate(schema, tags.Get("validate"))
+		op.Parameters = append(op.Parameters, &OAParameter{
+			Name: name, In: "query", Required: required, Schema: schema,
+			Description: strings.TrimSpace(oaFirstLine(field.Comment) + " " + oaFirstLine(field.Doc)),
+		})
+	}
+}
+
+// Return the schema of given type string, such as 'string', 'int',
+// 'types.Accout', '[]types.Accout', '*types.Accout', 'map[string]int'.
+func (g *oaGenerator) typeSchema(pkg, typ string) *OASchema {
+	typ = strings.TrimPrefix(strings.TrimSpace(typ), "*")
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		if typ == "[]byte" {
+			return &OASchema{Type: "string", Format: "byte"}
+		}
+		return &OASchema{Type: "array", Items: g.typeSchema(pkg, typ[2:])}
+	case strings.HasPrefix(typ, "map["):
+		if end := strings.Index(typ, "]"); end > 0 {
+			return &OASchema{Type: "object", AdditionalProperties: g.typeSchema(pkg, typ[end+1:])}
+		}
+	}
+
+	if schema := oaBuiltinSchema(typ); schema != nil {
+		return schema
+	}
+
+	key := typ
+	if !strings.Contains(key, ".") {
+		key = pkg + "." + typ
+	}
+	if _, ok := g.structs[key]; !ok {
+		return &OASchema{Type: "object"} // unknown type of other packages.
+	}
+
+	if _, ok := g.doc.Components.Schemas[key]; !ok {
+		g.doc.Components.Schemas[key] = &OASchema{} // avoid cycle references.
+		g.doc.Components.Schemas[key] = g.structSchema(key)
+	}
+	return &OASchema{Ref: _oa_schemas_ref + key}
+}
+
+// Return the object schema of parsed struct.
+func (g *oaGenerator) structSchema(key string) *OASchema {
+	s := g.structs[key]
+	schema := &OASchema{Type: "object", Description: s.doc, Properties: make(map[string]*OASchema)}
+	g.fillProperties(s, schema)
+	return schema
+}
+
+// Fill struct fields as object properties, and merge embedded fields.
+func (g *oaGenerator) fillProperties(s *oaStruct, schema *OASchema) {
+	for _, field := range s.node.Fields.List {
+		typ := oaTypeString(field.Type)
+		tags := oaFieldTags(field)
+
+		if len(field.Names) == 0 { // embedded struct
+			key := strings.TrimPrefix(typ, "*")
+			if !strings.Contains(key, ".") {
+				key = s.pkg + "." + key
+			}
+			if embed, ok := g.structs[key]; ok && tags.Get("json") == "" {
+				g.fillProperties(embed, schema)
+				continue
+			}
+		}
+
+		if strings.HasPrefix(typ, "chan ") {
+			continue // json not support chan fields.
+		}
+
+		for _, name := range oaFieldNames(field) {
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			jsonname, _, _ := strings.Cut(tags.Get("json"), ",")
+			if jsonname == "-" {
+				continue
+			} else if jsonname == "" {
+				jsonname = name
+			}
+
+			prop := g.typeSchema(s.pkg, typ)
+			if prop.Ref != "" && field.Doc == nil && field.Comment == nil && tags.Get("validate") == "" {
+				schema.Properties[jsonname] = prop
+				continue
+			}
+
+			prop.Description = strings.TrimSpace(oaFirstLine(field.Comment) + " " + oaFirstLine(field.Doc))
+			if oaApplyValidate(prop, tags.Get("validate")) {
+				schema.Required = append(schema.Required, jsonname)
+			}
+			schema.Properties[jsonname] = prop
+		}
+	}
+}
+
+/* ------------------------------------------------------------------- */
+/* For Internal OpenAPI Utils                                          */
+/* ------------------------------------------------------------------- */
+
+// Return the last (or first if set head) field type of func params or results.
+func oaLastParam(fields *ast.FieldList, head ...bool) string {
+	if fields == nil || len(fields.List) == 0 {
+		return ""
+	} else if len(head) > 0 && head[0] {
+		return oaTypeString(fields.List[0].Type)
+	}
+	return oaTypeString(fields.List[len(fields.List)-1].Type)
+}
+
+// Check the http methods whether parse input params from url.
+func oaUrlMethods(methods []string) bool {
+	for _, method := range methods {
+		if method != "get" && method != "head" && method != "delete" {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the components response key of error code, like 'E400'.
+func oaErrorKey(code int) string {
+	return "E" + strconv.Itoa(code)
+}
+
+// Return the first line of comments, or empty string if nil.
+func oaFirstLine(doc *ast.CommentGroup) string {
+	if doc != nil {
+		line, _, _ := strings.Cut(strings.TrimSpace(doc.Text()), "\n")
+		return line
+	}
+	return ""
+}
+
+// Return receiver type name of method, like 'AccController'.
+func oaRecvName(expr ast.Expr) string {
+	return strings.TrimPrefix(oaTypeString(expr), "*")
+}
+
+// Return the type expression as source code string.
+func oaTypeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + oaTypeString(t.X)
+	case *ast.SelectorExpr:
+		return oaTypeString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + oaTypeString(t.Elt)
+	case *ast.Ellipsis: // variadic params, same as slice.
+		return "[]" + oaTypeString(t.Elt)
+	case *ast.ChanType:
+		return "chan " + oaTypeString(t.Value)
+	case *ast.MapType:
+		return "map[" + oaTypeString(t.Key) + "]" + oaTypeString(t.Value)
+	case *ast.InterfaceType:
+		return "any"
+	case *ast.IndexExpr: // generic type, use base type.
+		return oaTypeString(t.X)
+	}
+	return ""
+}
+
+// Return composite literal type of '&types.Accout{}' expression.
+func oaCompositeType(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok && lit.Type != nil {
+		return oaTypeString(lit.Type)
+	}
+	return ""
+}
+
+// Return field names, or the type name for embedded field.
+func oaFieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		name := strings.TrimPrefix(oaTypeString(field.Type), "*")
+		return []string{name[strings.LastIndex(name, ".")+1:]}
+	}
+
+	names := []string{}
+	for _, ident := range field.Names {
+		names = append(names, ident.Name)
+	}
+	return names
+}
+
+// Return the struct field tags.
+func oaFieldTags(field *ast.Field) oaTags {
+	if field.Tag != nil {
+		if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+			return oaTags(tag)
+		}
+	}
+	return ""
+}
+
+// Struct field tags string.
+type oaTags string
+
+// Return tag value of given key, like reflect.StructTag.Get().
+func (t oaTags) Get(key string) string {
+	tags := string(t)
+	for tags != "" {
+		tags = strings.TrimLeft(tags, " ")
+		name, rest, ok := strings.Cut(tags, ":\"")
+		if !ok {
+			break
+		}
+
+		end := strings.Index(rest, "\"")
+		for end > 0 && rest[end-1] == '\\' {
+			if next := strings.Index(rest[end+1:], "\""); next >= 0 {
+				end += next + 1
+			} else {
+				end = -1
+			}
+		}
+		if end < 0 {
+			break
+		}
+		if name == key {
+			return rest[:end]
+		}
+		tags = rest[end+1:]
+	}
+	return ""
+}
+
+// Return schema of golang build-in types, or nil if not build-in type.
+func oaBuiltinSchema(typ string) *OASchema {
+	switch typ {
+	case "string":
+		return &OASchema{Type: "string"}
+	case "bool":
+		return &OASchema{Type: "boolean"}
+	case "int", "int8", "int16", "uint", "uint8", "uint16", "integer":
+		return &OASchema{Type: "integer"}
+	case "int32", "uint32":
+		return &OASchema{Type: "integer", Format: "int32"}
+	case "int64", "uint64":
+		return &OASchema{Type: "integer", Format: "int64"}
+	case "float32", "float":
+		return &OASchema{Type: "number", Format: "float"}
+	case "float64", "number":
+		return &OASchema{Type: "number", Format: "double"}
+	case "file":
+		return &OASchema{Type: "string", Format: "binary"}
+	case "time.Time":
+		return &OASchema{Type: "string", Format: "date-time"}
+	case "any", "interface{}", "object":
+		return &OASchema{}
+	}
+	return nil
+}
+
+// Apply validate tag rules to schema, and return true when required.
+//
+// The rules after 'dive' apply to array items, and the custom validators
+// will output as 'x-validate' extension value.
+func oaApplyValidate(schema *OASchema, rules string) bool {
+	if rules == "" {
+		return false
+	}
+
+	schema.Validate = rules
+	required, target := false, schema
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = target == schema
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "min", "max", "len":
+			oaApplyLimit(target, name, param)
+		case "gt", "gte", "lt", "lte":
+			if v, err := strconv.ParseFloat(param, 64); err == nil {
+				switch name {
+				case "gt":
+					target.ExclusiveMinimum = &v
+				case "gte":
+					target.Minimum = &v
+				case "lt":
+					target.ExclusiveMaximum = &v
+				case "lte":
+					target.Maximum = &v
+				}
+			}
+		case "oneof":
+			for _, item := range strings.Fields(param) {
+				if target.Type == "integer" || target.Type == "number" {
+					if v, err := strconv.ParseFloat(item, 64); err == nil {
+						target.Enum = append(target.Enum, v)
+						continue
+					}
+				}
+				target.Enum = append(target.Enum, item)
+			}
+		case "email":
+			target.Format = "email"
+		case "url", "uri":
+			target.Format = "uri"
+		case "uuid", "uuid4":
+			target.Format = "uuid"
+		case "ipv4", "ip4_addr":
+			target.Format = "ipv4"
+		case "ipv6", "ip6_addr":
+			target.Format = "ipv6"
+		case "numeric", "number":
+			target.Pattern = "^[-+]?[0-9]+(\\.[0-9]+)?$"
+		case "alpha":
+			target.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		}
+	}
+	return required
+}
+
+// Apply min, max, len limit by schema type.
+func oaApplyLimit(schema *OASchema, name, param string) {
+	v, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	n := int(v)
+	switch schema.Type {
+	case "string":
+		if name != "max" {
+			schema.MinLength = &n
+		}
+		if name != "min" {
+			schema.MaxLength = &n
+		}
+	case "array", "object":
+		if name != "max" {
+			schema.MinItems = &n
+		}
+		if name != "min" {
+			schema.MaxItems = &n
+		}
+	default: // integer, number
+		if name != "max" {
+			schema.Minimum = &v
+		}
+		if name != "min" {
+			schema.Maximum = &v
+		}
+	}
+}
+
+// Split annotation fields by spaces, and keep quoted string as one field.
+func oaSplitFields(value string) []string {
+	fields, quoted, field := []string{}, false, strings.Builder{}
+	for _, ch := range value {
+		switch {
+		case ch == '"':
+			if quoted {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			quoted = !quoted
+		case !quoted && (ch == ' ' || ch == '\t'):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(ch)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// Parse '/login [post]' or '/login [get,post]' router annotation.
+func oaParseRouter(value string) (string, []string) {
+	path, methods, _ := strings.Cut(value, " ")
+	methods = strings.Trim(strings.TrimSpace(methods), "[]")
+	if methods == "" {
+		methods = "get"
+	}
+
+	outs := []string{}
+	for _, method := range strings.Split(methods, ",") {
+		if method = strings.ToLower(strings.TrimSpace(method)); method != "" {
+			outs = append(outs, method)
+		}
+	}
+	return path, outs
+}
+
+// Convert beego router path to openapi path, like '/acc/:id' to '/acc/{id}'.
+func oaNormalizePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return "/" + strings.TrimLeft(strings.Join(segs, "/"), "/")
+}
+
+// Declare the undeclared '{name}' segments of path as required string path
+// params, openapi require all path templates declared.
+func oaPathParams(op *OAOperation, path string) {
+	for _, seg := range strings.Split(path, "/") {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+
+		name, declared := seg[1:len(seg)-1], false
+		for _, param := range op.Parameters {
+			if param.In == "path" && param.Name == name {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			op.Parameters = append(op.Parameters, &OAParameter{
+				Name: name, In: "path", Required: true, Schema: &OASchema{Type: "string"},
+			})
+		}
+	}
+}
@@ -11,7 +11,12 @@
 package xhttp
 
 import (
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/wengoldx/xcore/secure"
 )
 
 func TestGet(t *testing.T) {
@@ -79,3 +84,23 @@ func TestGetStruct2(t *testing.T) {
 	}
 	t.Log("Out struct:", out)
 }
+
+func TestSignRequest(t *testing.T) {
+	body := `{"uid":"20000680"}`
+	req, _ := http.NewRequest("POST", "http://127.0.0.1/v3/acc/detail?b=2&a=1", strings.NewReader(body))
+	if _, err := ChainRequest(AuthFunc("token"), SignFunc("ak", "secret"))(req); err != nil {
+		t.Fatal("Sign request, err:", err)
+	}
+
+	if data, _ := io.ReadAll(req.Body); string(data) != body {
+		t.Fatal("Request body not restored:", string(data))
+	} else if req.Header.Get("Token") != "token" {
+		t.Fatal("Auth header not set!")
+	}
+
+	h := req.Header
+	canonical := CanonicalRequest("post", "/v3/acc/detail", "a=1&b=2", HashBody([]byte(body)), "ak", h.Get(HeaderTimestamp), h.Get(HeaderNonce))
+	if h.Get(HeaderSignature) != secure.SignSHA256("secret", canonical) {
+		t.Fatal("Unmatched signature!")
+	}
+}
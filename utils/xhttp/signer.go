@@ -0,0 +1,129 @@
+// Copyright (c) 2018-Now Dunyu All Rights Reserved.
+//
+// Author      : https://www.wengold.net
+// Email       : support@wengold.net
+//
+// Prismy.No | Date       | Modified by. | Description
+// -------------------------------------------------------------------
+// 00001       2026/10/19   yangping       New version
+// -------------------------------------------------------------------
+
+package xhttp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wengoldx/xcore/secure"
+)
+
+// Request signature headers.
+const (
+	HeaderAccessKey   = "X-Access-Key"     // Access key to find signing secret
+	HeaderTimestamp   = "X-Timestamp"      // Unix seconds of signed time
+	HeaderNonce       = "X-Nonce"          // Random nonce to reject replays
+	HeaderContentHash = "X-Content-SHA256" // Hex sha256 of request body
+	HeaderSignature   = "X-Signature"      // Base64 HmacSHA256 signature
+)
+
+// Return the canonical string of request for signature, the query params
+// sorted by key, and all parts joined by new line chars.
+//
+//	POST
+//	/v3/acc/detail
+//	a=1&b=2
+//	e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//	accesskey
+//	1760842800
+//	5f2b...nonce
+func CanonicalRequest(method, path, rawquery, bodyhash, accesskey, timestamp, nonce string) string {
+	query, _ := url.ParseQuery(rawquery)
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		strings.ToUpper(method), path, query.Encode(), bodyhash, accesskey, timestamp, nonce,
+	}, "\n")
+}
+
+// Return the hex sha256 hash of request body.
+func HashBody(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// Sign request by access key and secret, it read and restore request body
+// to calculate body hash, then set signature headers.
+func SignRequest(req *http.Request, accesskey, secret string) error {
+	body := []byte{}
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		body, req.Body = buf, io.NopCloser(bytes.NewReader(buf))
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	bodyhash, timestamp := HashBody(body), strconv.FormatInt(time.Now().Unix(), 10)
+	noncestr := hex.EncodeToString(nonce)
+	canonical := CanonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.RawQuery, bodyhash, accesskey, timestamp, noncestr)
+
+	req.Header.Set(HeaderAccessKey, accesskey)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, noncestr)
+	req.Header.Set(HeaderContentHash, bodyhash)
+	req.Header.Set(HeaderSignature, secure.SignSHA256(secret, canonical))
+	return nil
+}
+
+// Create and return request signing callback, use ChainRequest() to combine
+// with AuthFunc() for both token and signature.
+//
+//	err := xhttp.ClientPost(tagurl, xhttp.SignFunc(ak, secret), &resp, params)
+func SignFunc(accesskey, secret string, ignore ...bool) SetRequest {
+	return func(req *http.Request) (bool, error) {
+		if err := SignRequest(req, accesskey, secret); err != nil {
+			return false, err
+		}
+		if len(ignore) > 0 {
+			return ignore[0], nil
+		}
+		return true, nil
+	}
+}
+
+// Combine multiple request callbacks in order, it return ignore TLS flag
+// when any callback returned true.
+//
+//	setter := xhttp.ChainRequest(xhttp.AuthFunc(token), xhttp.SignFunc(ak, secret))
+func ChainRequest(setters ...SetRequest) SetRequest {
+	return func(req *http.Request) (bool, error) {
+		ignore := false
+		for _, setter := range setters {
+			if setter == nil {
+				continue
+			}
+
+			skip, err := setter(req)
+			if err != nil {
+				return false, err
+			}
+			ignore = ignore || skip
+		}
+		return ignore, nil
+	}
+}